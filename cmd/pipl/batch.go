@@ -0,0 +1,515 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/xpcmdshell/pipl"
+)
+
+// rowColumn holds the (1-based) position of a record in the input file, so
+// interrupted runs can be resumed and results written out of order can be
+// matched back to their records.
+const rowColumn = "pipl_row"
+
+// batchOptions holds the flags of the batch command.
+type batchOptions struct {
+	in, out, mapping string
+	concurrency      int
+	rate             float64
+	resume           bool
+	quiet            bool
+}
+
+// batchRow is a record read from the input file.
+type batchRow struct {
+	index  int
+	record map[string]string
+	raw    map[string]json.RawMessage // JSONL input only
+}
+
+// batchResult is an enriched record.
+type batchResult struct {
+	row    batchRow
+	result *pipl.EnrichmentResult
+}
+
+func runBatch(args []string, stdout io.Writer, stderr io.Writer) int {
+	flags := flag.NewFlagSet("pipl batch", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	var options batchOptions
+	var settings clientFlags
+	flags.StringVar(&options.in, "in", "", "input `file`, CSV (.csv) or JSON Lines")
+	flags.StringVar(&options.out, "out", "", "output `file`, CSV (.csv) or JSON Lines (default JSON Lines on stdout)")
+	flags.StringVar(&options.mapping, "map", "", "mapping `file`, YAML or JSON")
+	flags.IntVar(&options.concurrency, "concurrency", 1, "number of searches run at once")
+	flags.Float64Var(&options.rate, "rate", 0, "maximum searches per second (0 for no limit)")
+	flags.BoolVar(&options.resume, "resume", false, "skip the records already in the output file, except failed ones, and append to it")
+	flags.BoolVar(&options.quiet, "quiet", false, "don't report progress")
+	settings.register(flags)
+	if err := flags.Parse(args); err != nil {
+		return exitUsage
+	}
+	if options.in == "" || options.mapping == "" || flags.NArg() > 0 {
+		fmt.Fprintln(stderr, "Usage: pipl batch -in records.csv -map mapping.yaml [-out results.jsonl] [flags]")
+		return exitUsage
+	}
+	if options.resume && options.out == "" {
+		fmt.Fprintln(stderr, "pipl batch: -resume needs -out")
+		return exitUsage
+	}
+	client, err := settings.client(stderr)
+	if err != nil {
+		fmt.Fprintf(stderr, "pipl batch: %v\n", err)
+		return exitError
+	}
+	code, err := batch(&options, client, stdout, stderr)
+	if err != nil {
+		fmt.Fprintf(stderr, "pipl batch: %v\n", err)
+		return exitError
+	}
+	return code
+}
+
+// batch enriches the input file and returns the exit code for the worst
+// outcome among the records.
+func batch(options *batchOptions, searcher pipl.Searcher, stdout io.Writer, stderr io.Writer) (int, error) {
+	mapping, err := loadMapping(options.mapping)
+	if err != nil {
+		return exitError, err
+	}
+	rows, header, err := readRows(options.in)
+	if err != nil {
+		return exitError, err
+	}
+	processed := make(map[int]bool)
+	if options.resume {
+		if processed, err = readProcessed(options.out); err != nil {
+			return exitError, err
+		}
+	}
+	pending := rows[:0:0]
+	for _, row := range rows {
+		if !processed[row.index] {
+			pending = append(pending, row)
+		}
+	}
+
+	var output io.Writer = stdout
+	appending := false
+	if options.out != "" {
+		mode := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+		if options.resume {
+			mode = os.O_CREATE | os.O_WRONLY | os.O_APPEND
+		}
+		file, err := os.OpenFile(options.out, mode, 0644)
+		if err != nil {
+			return exitError, err
+		}
+		defer file.Close()
+		if info, err := file.Stat(); err == nil {
+			appending = info.Size() > 0
+		}
+		output = file
+	}
+	guarded := &guardedSearcher{searcher: limit(searcher, options.rate)}
+	enricher := &pipl.Enricher{Searcher: guarded, Mapping: mapping.EnrichmentMapping, MinMatch: mapping.MinMatch}
+	writer := newBatchWriter(output, isCSV(options.out), header, enricher.Mapping.OutputColumns(), appending)
+
+	counts := make(map[pipl.EnrichmentStatus]int)
+	done := len(rows) - len(pending)
+	worst := pipl.EnrichmentOK
+	for result := range enrichRows(enricher, pending, options.concurrency, guarded.stopped) {
+		if err := writer.write(result); err != nil {
+			return exitError, err
+		}
+		status := result.result.Status
+		counts[status]++
+		done++
+		if exitRanks[status] > exitRanks[worst] {
+			worst = status
+		}
+		if !options.quiet {
+			fmt.Fprintf(stderr, "\r%d/%d records: %d ok, %d no match, %d ambiguous, %d invalid, %d errors",
+				done, len(rows), counts[pipl.EnrichmentOK], counts[pipl.EnrichmentNoMatch], counts[pipl.EnrichmentAmbiguous],
+				counts[pipl.EnrichmentInvalid], counts[pipl.EnrichmentError])
+		}
+	}
+	if !options.quiet && len(pending) > 0 {
+		fmt.Fprintln(stderr)
+	}
+	if err := writer.flush(); err != nil {
+		return exitError, err
+	}
+	if err := guarded.stopped(); err != nil {
+		return exitError, err
+	}
+	return exitCodes[worst], nil
+}
+
+// exitCodes maps record outcomes to exit codes, and exitRanks orders them
+// from best to worst.
+var (
+	exitCodes = map[pipl.EnrichmentStatus]int{
+		pipl.EnrichmentOK:        exitOK,
+		pipl.EnrichmentNoMatch:   exitNoMatch,
+		pipl.EnrichmentAmbiguous: exitAmbiguous,
+		pipl.EnrichmentInvalid:   exitError,
+		pipl.EnrichmentError:     exitError,
+	}
+	exitRanks = map[pipl.EnrichmentStatus]int{
+		pipl.EnrichmentOK:        0,
+		pipl.EnrichmentNoMatch:   1,
+		pipl.EnrichmentAmbiguous: 2,
+		pipl.EnrichmentInvalid:   3,
+		pipl.EnrichmentError:     3,
+	}
+)
+
+// enrichRows enriches rows with concurrency workers, delivering results as
+// they complete. Once stopped returns an error, the remaining rows are skipped
+// and left out of the results, so a resumed run picks them up.
+func enrichRows(enricher *pipl.Enricher, rows []batchRow, concurrency int, stopped func() error) <-chan batchResult {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	jobs := make(chan batchRow)
+	results := make(chan batchResult)
+	var wait sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		wait.Add(1)
+		go func() {
+			defer wait.Done()
+			for row := range jobs {
+				result := enricher.Enrich(row.record)
+				if result.Status == pipl.EnrichmentError && stopped() != nil {
+					continue
+				}
+				results <- batchResult{row: row, result: result}
+			}
+		}()
+	}
+	go func() {
+		for _, row := range rows {
+			if stopped() != nil {
+				break
+			}
+			jobs <- row
+		}
+		close(jobs)
+		wait.Wait()
+		close(results)
+	}()
+	return results
+}
+
+// limitedSearcher spaces searches out to respect a rate limit.
+type limitedSearcher struct {
+	searcher pipl.Searcher
+	ticks    <-chan time.Time
+}
+
+// limit wraps searcher so it runs at most rate searches per second. A rate of
+// zero means no limit.
+func limit(searcher pipl.Searcher, rate float64) pipl.Searcher {
+	if rate <= 0 {
+		return searcher
+	}
+	return &limitedSearcher{searcher: searcher, ticks: time.Tick(time.Duration(float64(time.Second) / rate))}
+}
+
+func (limited *limitedSearcher) SearchByPerson(searchObject *pipl.Person) (*pipl.Response, error) {
+	<-limited.ticks
+	return limited.searcher.SearchByPerson(searchObject)
+}
+
+func (limited *limitedSearcher) SearchByPointer(searchPointer string) (*pipl.Person, error) {
+	<-limited.ticks
+	return limited.searcher.SearchByPointer(searchPointer)
+}
+
+// rateLimitBackoff is how long a batch waits before retrying its first rate
+// limited search. The wait doubles with every retry, up to rateLimitRetries.
+var (
+	rateLimitBackoff = time.Second
+	rateLimitRetries = 5
+)
+
+// guardedSearcher stops a batch once the Pipl service rejects the API key (a
+// 401 or 403), since every later search would fail the same way, and retries
+// rate limited (429) searches with an exponential backoff.
+type guardedSearcher struct {
+	searcher pipl.Searcher
+	lock     sync.Mutex
+	err      error
+}
+
+// stopped returns the error that stopped the batch, if any.
+func (guarded *guardedSearcher) stopped() error {
+	guarded.lock.Lock()
+	defer guarded.lock.Unlock()
+	return guarded.err
+}
+
+// check inspects the outcome of a search. It reports whether the search should
+// be retried after a rate limit, and records fatal errors.
+func (guarded *guardedSearcher) check(err error, attempt int) bool {
+	apiErr, ok := err.(*pipl.ErrAPI)
+	if !ok {
+		return false
+	}
+	switch apiErr.StatusCode {
+	case http.StatusUnauthorized, http.StatusForbidden:
+		guarded.lock.Lock()
+		if guarded.err == nil {
+			guarded.err = fmt.Errorf("stopped after the Pipl service rejected the API key: %v", apiErr)
+		}
+		guarded.lock.Unlock()
+	case http.StatusTooManyRequests:
+		if attempt < rateLimitRetries {
+			time.Sleep(rateLimitBackoff << uint(attempt))
+			return true
+		}
+	}
+	return false
+}
+
+func (guarded *guardedSearcher) SearchByPerson(searchObject *pipl.Person) (*pipl.Response, error) {
+	for attempt := 0; ; attempt++ {
+		if err := guarded.stopped(); err != nil {
+			return nil, err
+		}
+		response, err := guarded.searcher.SearchByPerson(searchObject)
+		checked := err
+		if checked == nil && response != nil {
+			checked = response.Err()
+		}
+		if !guarded.check(checked, attempt) {
+			return response, err
+		}
+	}
+}
+
+func (guarded *guardedSearcher) SearchByPointer(searchPointer string) (*pipl.Person, error) {
+	for attempt := 0; ; attempt++ {
+		if err := guarded.stopped(); err != nil {
+			return nil, err
+		}
+		person, err := guarded.searcher.SearchByPointer(searchPointer)
+		if !guarded.check(err, attempt) {
+			return person, err
+		}
+	}
+}
+
+// isCSV reports whether path names a CSV file.
+func isCSV(path string) bool {
+	return strings.EqualFold(filepath.Ext(path), ".csv")
+}
+
+// readRows reads the records of a CSV or JSON Lines file, along with the
+// column order: the CSV header, or the members of the first JSON record.
+func readRows(path string) ([]batchRow, []string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer file.Close()
+	var rows []batchRow
+	if isCSV(path) {
+		in := csv.NewReader(file)
+		in.FieldsPerRecord = -1
+		header, err := in.Read()
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s: %v", path, err)
+		}
+		for index := 1; ; index++ {
+			values, err := in.Read()
+			if err == io.EOF {
+				return rows, header, nil
+			}
+			if err != nil {
+				return nil, nil, fmt.Errorf("%s: %v", path, err)
+			}
+			record := make(map[string]string, len(header))
+			for i, column := range header {
+				if i < len(values) {
+					record[column] = values[i]
+				}
+			}
+			rows = append(rows, batchRow{index: index, record: record})
+		}
+	}
+
+	var header []string
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for line, index := 1, 1; scanner.Scan(); line++ {
+		data := bytes.TrimSpace(scanner.Bytes())
+		if len(data) == 0 {
+			continue
+		}
+		var raw map[string]json.RawMessage
+		if err := json.Unmarshal(data, &raw); err != nil {
+			return nil, nil, fmt.Errorf("%s:%d: %v", path, line, err)
+		}
+		record := make(map[string]string, len(raw))
+		columns := make([]string, 0, len(raw))
+		for name, value := range raw {
+			columns = append(columns, name)
+			var text string
+			if err := json.Unmarshal(value, &text); err == nil {
+				record[name] = text
+			} else if string(value) != "null" {
+				record[name] = string(value)
+			}
+		}
+		sort.Strings(columns)
+		if header == nil {
+			header = columns
+		}
+		rows = append(rows, batchRow{index: index, record: record, raw: raw})
+		index++
+	}
+	return rows, header, scanner.Err()
+}
+
+// readProcessed returns the input rows already present in an output file. Rows
+// whose search failed (the error status) are left out so they're retried; their
+// new result is appended after the failed one. A missing file means nothing was
+// processed yet.
+func readProcessed(path string) (map[int]bool, error) {
+	processed := make(map[int]bool)
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return processed, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	if isCSV(path) {
+		rows, err := csv.NewReader(file).ReadAll()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", path, err)
+		}
+		column, statusColumn := -1, -1
+		for i, rowIndex := range rows {
+			if i == 0 {
+				for j, name := range rowIndex {
+					switch name {
+					case rowColumn:
+						column = j
+					case pipl.EnrichmentStatusColumn:
+						statusColumn = j
+					}
+				}
+				if column < 0 {
+					return nil, fmt.Errorf("%s: no %s column to resume from", path, rowColumn)
+				}
+				continue
+			}
+			if column < len(rowIndex) && (statusColumn < 0 || statusColumn >= len(rowIndex) || rowIndex[statusColumn] != string(pipl.EnrichmentError)) {
+				if index, err := strconv.Atoi(rowIndex[column]); err == nil {
+					processed[index] = true
+				}
+			}
+		}
+		return processed, nil
+	}
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var record struct {
+			Row    int                   `json:"pipl_row"`
+			Status pipl.EnrichmentStatus `json:"pipl_status"`
+		}
+		// A partially written last line is simply processed again
+		if json.Unmarshal(scanner.Bytes(), &record) == nil && record.Row > 0 && record.Status != pipl.EnrichmentError {
+			processed[record.Row] = true
+		}
+	}
+	return processed, scanner.Err()
+}
+
+// batchWriter writes enriched records.
+type batchWriter struct {
+	csv     *csv.Writer
+	jsonl   *bufio.Writer
+	columns []string
+}
+
+// newBatchWriter writes CSV (with a header, unless appending to an existing
+// file) or JSON Lines. CSV columns are the input columns followed by the
+// output columns and the row number.
+func newBatchWriter(w io.Writer, asCSV bool, header []string, outputs []string, appending bool) *batchWriter {
+	if !asCSV {
+		return &batchWriter{jsonl: bufio.NewWriter(w)}
+	}
+	writer := &batchWriter{csv: csv.NewWriter(w)}
+	writer.columns = append(append(append(writer.columns, header...), outputs...), rowColumn)
+	if !appending {
+		writer.csv.Write(writer.columns)
+	}
+	return writer
+}
+
+func (writer *batchWriter) write(result batchResult) error {
+	if writer.csv != nil {
+		values := make([]string, len(writer.columns))
+		for i, column := range writer.columns {
+			if value, ok := result.result.Values[column]; ok {
+				values[i] = value
+			} else {
+				values[i] = result.row.record[column]
+			}
+		}
+		values[len(values)-1] = strconv.Itoa(result.row.index)
+		writer.csv.Write(values)
+		// Flush every record, so an interrupted run can be resumed
+		writer.csv.Flush()
+		return writer.csv.Error()
+	}
+
+	members := make(map[string]json.RawMessage, len(result.row.record)+len(result.result.Values)+1)
+	for name, value := range result.row.record {
+		encoded, _ := json.Marshal(value)
+		members[name] = encoded
+	}
+	for name, value := range result.row.raw {
+		members[name] = value
+	}
+	for name, value := range result.result.Values {
+		encoded, _ := json.Marshal(value)
+		members[name] = encoded
+	}
+	members[rowColumn] = json.RawMessage(strconv.Itoa(result.row.index))
+	encoded, err := json.Marshal(members)
+	if err != nil {
+		return err
+	}
+	writer.jsonl.Write(encoded)
+	writer.jsonl.WriteByte('\n')
+	return writer.jsonl.Flush()
+}
+
+func (writer *batchWriter) flush() error {
+	if writer.csv != nil {
+		writer.csv.Flush()
+		return writer.csv.Error()
+	}
+	return writer.jsonl.Flush()
+}
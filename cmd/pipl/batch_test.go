@@ -0,0 +1,246 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/xpcmdshell/pipl"
+)
+
+// countingSearcher answers searches with canned responses keyed by email, and
+// counts the searches.
+type countingSearcher struct {
+	lock      sync.Mutex
+	responses map[string]*pipl.Response
+	searches  int
+}
+
+func (searcher *countingSearcher) SearchByPerson(searchObject *pipl.Person) (*pipl.Response, error) {
+	searcher.lock.Lock()
+	defer searcher.lock.Unlock()
+	searcher.searches++
+	for _, email := range searchObject.Emails {
+		if response, ok := searcher.responses[email.Address]; ok {
+			return response, nil
+		}
+	}
+	return &pipl.Response{}, nil
+}
+
+func (searcher *countingSearcher) SearchByPointer(searchPointer string) (*pipl.Person, error) {
+	return &pipl.Person{}, nil
+}
+
+func writeFile(t *testing.T, dir string, name string, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestParseMappingYAML(t *testing.T) {
+	var mapping batchMapping
+	err := parseMappingYAML(`# CRM export
+columns:
+  full_name: name
+  "e-mail": email   # work address
+outputs:
+  - address
+  - 'job_title'
+prefix: enriched_
+min_match: 0.8
+`, &mapping)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mapping.Columns["full_name"] != "name" || mapping.Columns["e-mail"] != "email" || len(mapping.Outputs) != 2 ||
+		mapping.Outputs[1] != "job_title" || mapping.Prefix != "enriched_" || mapping.MinMatch != 0.8 {
+		t.Errorf("unexpected mapping: %+v", mapping)
+	}
+	if err := parseMappingYAML("columns:\n  - name\n", &mapping); err == nil {
+		t.Error("expected an error for a list of columns")
+	}
+	if err := parseMappingYAML("colums:\n  name: name\n", &mapping); err == nil {
+		t.Error("expected an error for an unknown key")
+	}
+}
+
+func TestBatch(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pipl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	options := &batchOptions{
+		in: writeFile(t, dir, "records.csv", "id,full_name,email\n"+
+			"1,Clark Kent,clark@dailyplanet.example.com\n"+
+			"2,Bruce Wayne,bruce@example.com\n"+
+			"3,C. Kent,kent@example.com\n"),
+		mapping:     writeFile(t, dir, "mapping.yaml", "columns:\n  email: email\noutputs:\n  - job_title\n  - match\n"),
+		out:         filepath.Join(dir, "results.csv"),
+		concurrency: 2,
+		quiet:       true,
+	}
+	searcher := &countingSearcher{responses: map[string]*pipl.Response{
+		"clark@dailyplanet.example.com": loadResponse(t, "person_response.json"),
+		"kent@example.com":              loadResponse(t, "possible_persons_response.json"),
+	}}
+
+	code, err := batch(options, searcher, ioutil.Discard, ioutil.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if code != exitAmbiguous {
+		t.Errorf("expected the ambiguous exit code, got %d", code)
+	}
+	file, err := os.Open(options.out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows, err := csv.NewReader(file).ReadAll()
+	file.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(rows[0], ",") != "id,full_name,email,pipl_job_title,pipl_match,pipl_status,pipl_error,pipl_row" || len(rows) != 4 {
+		t.Fatalf("unexpected output: %q", rows)
+	}
+	statuses := make(map[string]string)
+	for _, row := range rows[1:] {
+		statuses[row[0]] = row[5]
+		if row[0] == "1" && row[3] != "Field Reporter" {
+			t.Errorf("unexpected enrichment: %q", row)
+		}
+	}
+	if statuses["1"] != "ok" || statuses["2"] != "no_match" || statuses["3"] != "ambiguous" {
+		t.Errorf("unexpected statuses: %v", statuses)
+	}
+
+	// Keep the header and the first result, as if the run had been interrupted
+	var kept []string
+	for _, row := range rows[:2] {
+		kept = append(kept, strings.Join(row, ","))
+	}
+	writeFile(t, dir, "results.csv", strings.Join(kept, "\n")+"\n")
+	searcher.searches = 0
+	options.resume = true
+	if _, err := batch(options, searcher, ioutil.Discard, ioutil.Discard); err != nil {
+		t.Fatal(err)
+	}
+	if searcher.searches != 2 {
+		t.Errorf("expected 2 searches when resuming, got %d", searcher.searches)
+	}
+	data, _ := ioutil.ReadFile(options.out)
+	if lines := strings.Split(strings.TrimSpace(string(data)), "\n"); len(lines) != 4 || strings.Count(string(data), "pipl_row") != 1 {
+		t.Errorf("unexpected resumed output:\n%s", data)
+	}
+}
+
+func TestBatchJSONL(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pipl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	options := &batchOptions{
+		in:      writeFile(t, dir, "records.jsonl", `{"email": "clark@dailyplanet.example.com", "crm_id": 42}`+"\n"),
+		mapping: writeFile(t, dir, "mapping.json", `{"columns": {"email": "email"}}`),
+		out:     filepath.Join(dir, "results.jsonl"),
+		quiet:   true,
+	}
+	searcher := &countingSearcher{responses: map[string]*pipl.Response{"clark@dailyplanet.example.com": loadResponse(t, "person_response.json")}}
+	code, err := batch(options, searcher, ioutil.Discard, ioutil.Discard)
+	if err != nil || code != exitOK {
+		t.Fatalf("unexpected outcome: %d, %v", code, err)
+	}
+	data, _ := ioutil.ReadFile(options.out)
+	var record map[string]interface{}
+	if err := json.Unmarshal(data, &record); err != nil {
+		t.Fatal(err)
+	}
+	if record["crm_id"] != 42.0 || record["pipl_row"] != 1.0 || record["pipl_status"] != "ok" || record["pipl_address"] == "" {
+		t.Errorf("unexpected record: %v", record)
+	}
+}
+
+// throttledSearcher answers the first searches with a rate limit error.
+type throttledSearcher struct {
+	countingSearcher
+	throttled int
+}
+
+func (searcher *throttledSearcher) SearchByPerson(searchObject *pipl.Person) (*pipl.Response, error) {
+	if searcher.throttled > 0 {
+		searcher.throttled--
+		return &pipl.Response{HTTPStatusCode: 429, Error: "Too many requests"}, nil
+	}
+	return searcher.countingSearcher.SearchByPerson(searchObject)
+}
+
+func TestBatchAPIErrors(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pipl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	backoff := rateLimitBackoff
+	rateLimitBackoff = time.Millisecond
+	defer func() { rateLimitBackoff = backoff }()
+	options := &batchOptions{
+		in: writeFile(t, dir, "records.csv", "id,email\n"+
+			"1,clark@dailyplanet.example.com\n"+
+			"2,bruce@example.com\n"+
+			"3,kent@example.com\n"),
+		mapping: writeFile(t, dir, "mapping.yaml", "columns:\n  email: email\n"),
+		out:     filepath.Join(dir, "results.csv"),
+		quiet:   true,
+	}
+
+	// A rejected key stops the batch without writing the remaining records
+	rejected := &countingSearcher{responses: map[string]*pipl.Response{"clark@dailyplanet.example.com": loadResponse(t, "error_response.json")}}
+	if code, err := batch(options, rejected, ioutil.Discard, ioutil.Discard); err == nil || code != exitError || rejected.searches != 1 {
+		t.Errorf("expected the batch to stop after 1 search, got %d searches (%d, %v)", rejected.searches, code, err)
+	}
+
+	// Failed records are retried when resuming, and rate limits are waited out
+	writeFile(t, dir, "results.csv", "id,email,pipl_status,pipl_error,pipl_row\n"+
+		"1,clark@dailyplanet.example.com,error,quota,1\n"+
+		"2,bruce@example.com,no_match,,2\n")
+	options.resume = true
+	searcher := &throttledSearcher{throttled: 2}
+	searcher.responses = map[string]*pipl.Response{"clark@dailyplanet.example.com": loadResponse(t, "person_response.json")}
+	if _, err := batch(options, searcher, ioutil.Discard, ioutil.Discard); err != nil {
+		t.Fatal(err)
+	}
+	if searcher.searches != 2 || searcher.throttled != 0 {
+		t.Errorf("expected records 1 and 3 to be searched, got %d searches", searcher.searches)
+	}
+	data, _ := ioutil.ReadFile(options.out)
+	if !strings.Contains(string(data), ",ok,,1\n") || strings.Count(string(data), "\n") != 5 {
+		t.Errorf("unexpected resumed output:\n%s", data)
+	}
+}
+
+func TestExitCode(t *testing.T) {
+	if code := exitCode(loadResponse(t, "person_response.json")); code != exitOK {
+		t.Errorf("expected %d for a full profile, got %d", exitOK, code)
+	}
+	if code := exitCode(loadResponse(t, "possible_persons_response.json")); code != exitAmbiguous {
+		t.Errorf("expected %d for possible persons, got %d", exitAmbiguous, code)
+	}
+	if code := exitCode(&pipl.Response{}); code != exitNoMatch {
+		t.Errorf("expected %d for no match, got %d", exitNoMatch, code)
+	}
+	if code := exitCode(loadResponse(t, "error_response.json")); code != exitError {
+		t.Errorf("expected %d for an API error, got %d", exitError, code)
+	}
+}
@@ -0,0 +1,141 @@
+// Command pipl runs Pipl searches from the command line.
+//
+// Usage:
+//
+//	pipl search [flags]
+//	pipl pointer [flags] <search pointer>
+//	pipl batch -in records.csv -map mapping.yaml -out results.jsonl [flags]
+//	pipl shell [flags]
+//
+// The exit code tells the outcome apart: 0 for a match, 3 when nothing
+// matched, 4 when the search matched several possible persons, 1 for errors
+// and 2 for usage errors. batch exits with the worst outcome among its records.
+//
+// The API key is read from the PIPL_API_KEY environment variable or, failing
+// that, from the "api_key" member of a JSON config file ($XDG_CONFIG_HOME/pipl/config.json
+// by default, see -config). Run "pipl search -h" for the list of flags.
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/xpcmdshell/pipl"
+)
+
+// command is a subcommand of the tool. run returns the exit code.
+type command struct {
+	summary string
+	run     func(args []string, stdout io.Writer, stderr io.Writer) int
+}
+
+var commands = map[string]command{
+	"search":  {"search by name, email, phone, username, user ID, URL or address", runSearch},
+	"pointer": {"fetch the full profile of a possible person by search pointer", runPointer},
+	"batch":   {"enrich a CSV or JSONL file of records", runBatch},
+	"shell":   {"refine searches interactively", runShell},
+}
+
+// Exit codes
+const (
+	exitOK        = 0
+	exitError     = 1
+	exitUsage     = 2
+	exitNoMatch   = 3
+	exitAmbiguous = 4
+)
+
+// exitCode returns the exit code for the outcome of a search. API errors are
+// checked first, as those responses hold no persons either.
+func exitCode(response *pipl.Response) int {
+	switch {
+	case response.Err() != nil:
+		return exitError
+	case response.IsEmpty():
+		return exitNoMatch
+	case response.IsAmbiguous():
+		return exitAmbiguous
+	}
+	return exitOK
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdout io.Writer, stderr io.Writer) int {
+	if len(args) == 0 || args[0] == "-h" || args[0] == "-help" || args[0] == "help" {
+		usage(stderr)
+		return exitUsage
+	}
+	cmd, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprintf(stderr, "pipl: unknown command %q\n", args[0])
+		usage(stderr)
+		return exitUsage
+	}
+	return cmd.run(args[1:], stdout, stderr)
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: pipl <command> [flags]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(w, "  %-8s %s\n", name, commands[name].summary)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Run 'pipl <command> -h' for the flags of a command.")
+}
+
+// config is the content of the config file.
+type config struct {
+	APIKey string `json:"api_key"`
+}
+
+// defaultConfigPath returns the default location of the config file.
+func defaultConfigPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "pipl", "config.json")
+}
+
+// loadAPIKey returns the API key from the PIPL_API_KEY environment variable or
+// the config file at path.
+func loadAPIKey(path string) (string, error) {
+	if key := strings.TrimSpace(os.Getenv("PIPL_API_KEY")); key != "" {
+		return key, nil
+	}
+	if path == "" {
+		return "", errors.New("no API key: set PIPL_API_KEY or use -config")
+	}
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return "", fmt.Errorf("no API key: set PIPL_API_KEY or add it to %s", path)
+	}
+	if err != nil {
+		return "", err
+	}
+	var settings config
+	if err := json.Unmarshal(data, &settings); err != nil {
+		return "", fmt.Errorf("%s: %v", path, err)
+	}
+	if settings.APIKey == "" {
+		return "", fmt.Errorf("%s: no api_key", path)
+	}
+	return settings.APIKey, nil
+}
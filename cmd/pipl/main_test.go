@@ -0,0 +1,126 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/xpcmdshell/pipl"
+)
+
+func loadResponse(t *testing.T, name string) *pipl.Response {
+	t.Helper()
+	data, err := ioutil.ReadFile(filepath.Join("..", "..", "testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	response := new(pipl.Response)
+	if err := json.Unmarshal(data, response); err != nil {
+		t.Fatal(err)
+	}
+	return response
+}
+
+func TestLoadAPIKey(t *testing.T) {
+	defer os.Setenv("PIPL_API_KEY", os.Getenv("PIPL_API_KEY"))
+	dir, err := ioutil.TempDir("", "pipl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "config.json")
+	if err := ioutil.WriteFile(path, []byte(`{"api_key": "from-file"}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	os.Setenv("PIPL_API_KEY", "from-env")
+	if key, err := loadAPIKey(path); err != nil || key != "from-env" {
+		t.Errorf("expected the environment to win, got %q, %v", key, err)
+	}
+	os.Setenv("PIPL_API_KEY", "")
+	if key, err := loadAPIKey(path); err != nil || key != "from-file" {
+		t.Errorf("expected the config file key, got %q, %v", key, err)
+	}
+	if _, err := loadAPIKey(filepath.Join(dir, "missing.json")); err == nil {
+		t.Error("expected an error without a key")
+	}
+}
+
+func TestSearchFlags(t *testing.T) {
+	flags := flag.NewFlagSet("test", flag.ContinueOnError)
+	var terms searchTerms
+	var settings clientFlags
+	terms.register(flags)
+	settings.register(flags)
+	err := flags.Parse([]string{"-email", "a@example.com", "-email", "b@example.com", "-first", "Clark", "-last", "Kent",
+		"-phone", "+1 978-555-0145", "-show-sources", "all", "-live-feeds=false", "-match-requirements", "phone"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	person := terms.searchObject()
+	if len(person.Emails) != 2 || len(person.Names) != 1 || person.Names[0].Last != "Kent" || person.Phones[0].Raw != "+1 978-555-0145" {
+		t.Errorf("unexpected search object: %+v", person)
+	}
+
+	defer os.Setenv("PIPL_API_KEY", os.Getenv("PIPL_API_KEY"))
+	os.Setenv("PIPL_API_KEY", "key")
+	client, err := settings.client(ioutil.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parameters := client.SearchParameters
+	if parameters.ShowSources != pipl.ShowSourcesAll || parameters.LiveFeeds || parameters.MatchRequirements != "phone" || parameters.MinimumProbability != 0.9 {
+		t.Errorf("unexpected parameters: %+v", parameters)
+	}
+
+	settings.showSources = "some"
+	if _, err := settings.client(ioutil.Discard); err == nil {
+		t.Error("expected an error for an invalid -show-sources")
+	}
+}
+
+func TestSummary(t *testing.T) {
+	var output bytes.Buffer
+	if err := writeSummary(&output, loadResponse(t, "person_response.json")); err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{"Clark Joseph Kent", "  Phones:     +1 978-555-0145\n              +1 617-555-0123 x12\n", "Jonathan Kent (Father)"} {
+		if !strings.Contains(output.String(), expected) {
+			t.Errorf("summary is missing %q:\n%s", expected, output.String())
+		}
+	}
+
+	output.Reset()
+	if err := writeSummary(&output, loadResponse(t, "possible_persons_response.json")); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(output.String(), "2 possible persons") || !strings.Contains(output.String(), "Pointer:") {
+		t.Errorf("unexpected summary:\n%s", output.String())
+	}
+
+	output.Reset()
+	if err := writeSummary(&output, loadResponse(t, "error_response.json")); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(output.String(), "Error: The API key is invalid") || strings.Contains(output.String(), "No match.") {
+		t.Errorf("unexpected summary:\n%s", output.String())
+	}
+}
+
+func TestRunUsage(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if code := run(nil, &stdout, &stderr); code != exitUsage || !strings.Contains(stderr.String(), "search") {
+		t.Errorf("unexpected usage (%d): %s", code, stderr.String())
+	}
+	if code := run([]string{"frobnicate"}, &stdout, &stderr); code != exitUsage {
+		t.Errorf("expected a usage error for an unknown command, got %d", code)
+	}
+	if code := run([]string{"search", "-bogus"}, &stdout, &stderr); code != exitUsage {
+		t.Errorf("expected a usage error for an unknown flag, got %d", code)
+	}
+}
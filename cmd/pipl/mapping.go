@@ -0,0 +1,143 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/xpcmdshell/pipl"
+)
+
+// batchMapping is the content of a batch mapping file: the enrichment mapping,
+// plus the match confidence needed to resolve ambiguous records.
+type batchMapping struct {
+	pipl.EnrichmentMapping
+	MinMatch float32 `json:"min_match,omitempty"`
+}
+
+// loadMapping reads a mapping file, in JSON or in YAML (.yaml, .yml). Only the
+// subset of YAML a mapping needs is supported:
+//
+//	columns:
+//	  full_name: name
+//	  email: email
+//	outputs:
+//	  - address
+//	  - job_title
+//	prefix: pipl_
+//	min_match: 0.8
+func loadMapping(path string) (*batchMapping, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	mapping := new(batchMapping)
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = parseMappingYAML(string(data), mapping)
+	default:
+		err = json.Unmarshal(data, mapping)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	if len(mapping.Columns) == 0 {
+		return nil, fmt.Errorf("%s: no columns mapped", path)
+	}
+	if err := mapping.Validate(); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return mapping, nil
+}
+
+// parseMappingYAML parses the YAML subset described in loadMapping.
+func parseMappingYAML(data string, mapping *batchMapping) error {
+	section := ""
+	for number, line := range strings.Split(data, "\n") {
+		if hash := strings.Index(line, " #"); hash >= 0 {
+			line = line[:hash]
+		}
+		if strings.HasPrefix(strings.TrimSpace(line), "#") || strings.TrimSpace(line) == "" {
+			continue
+		}
+		indented := line[0] == ' ' || line[0] == '\t'
+		line = strings.TrimSpace(line)
+		fail := func(message string) error {
+			return fmt.Errorf("line %d: %s", number+1, message)
+		}
+
+		if !indented {
+			key, value, ok := splitYAMLPair(line)
+			if !ok {
+				return fail("expected \"key: value\"")
+			}
+			section = ""
+			switch key {
+			case "columns", "outputs":
+				if value != "" {
+					return fail(key + " must be a nested block")
+				}
+				section = key
+				if key == "columns" && mapping.Columns == nil {
+					mapping.Columns = make(map[string]string)
+				}
+			case "prefix":
+				mapping.Prefix = value
+			case "min_match":
+				minMatch, err := strconv.ParseFloat(value, 32)
+				if err != nil {
+					return fail("invalid min_match")
+				}
+				mapping.MinMatch = float32(minMatch)
+			default:
+				return fail(fmt.Sprintf("unknown key %q", key))
+			}
+			continue
+		}
+
+		switch section {
+		case "columns":
+			column, term, ok := splitYAMLPair(line)
+			if !ok || term == "" {
+				return fail("expected \"column: term\"")
+			}
+			mapping.Columns[column] = term
+		case "outputs":
+			if !strings.HasPrefix(line, "- ") {
+				return fail("expected \"- output\"")
+			}
+			mapping.Outputs = append(mapping.Outputs, unquoteYAML(strings.TrimSpace(line[2:])))
+		default:
+			return fail("unexpected indentation")
+		}
+	}
+	return nil
+}
+
+// splitYAMLPair splits "key: value", unquoting both.
+func splitYAMLPair(line string) (key string, value string, ok bool) {
+	colon := strings.Index(line, ":")
+	if strings.HasPrefix(line, `"`) {
+		if end := strings.Index(line[1:], `"`); end >= 0 {
+			colon = strings.Index(line[end+2:], ":")
+			if colon >= 0 {
+				colon += end + 2
+			}
+		}
+	}
+	if colon <= 0 {
+		return "", "", false
+	}
+	return unquoteYAML(strings.TrimSpace(line[:colon])), unquoteYAML(strings.TrimSpace(line[colon+1:])), true
+}
+
+// unquoteYAML strips the quotes around a scalar.
+func unquoteYAML(value string) string {
+	if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+		return value[1 : len(value)-1]
+	}
+	return value
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/xpcmdshell/pipl"
+)
+
+func runPointer(args []string, stdout io.Writer, stderr io.Writer) int {
+	flags := flag.NewFlagSet("pipl pointer", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	var settings clientFlags
+	settings.register(flags)
+	asJSON := flags.Bool("json", false, "print the person as JSON")
+	if err := flags.Parse(args); err != nil {
+		return exitUsage
+	}
+	if flags.NArg() != 1 {
+		fmt.Fprintln(stderr, "Usage: pipl pointer [flags] <search pointer>")
+		return exitUsage
+	}
+	client, err := settings.client(stderr)
+	if err != nil {
+		fmt.Fprintf(stderr, "pipl pointer: %v\n", err)
+		return exitError
+	}
+	person, err := client.SearchByPointer(flags.Arg(0))
+	if err != nil {
+		fmt.Fprintf(stderr, "pipl pointer: %v\n", err)
+		return exitError
+	}
+	if err := writePointerResult(stdout, person, *asJSON); err != nil {
+		fmt.Fprintf(stderr, "pipl pointer: %v\n", err)
+		return exitError
+	}
+	// A pointer that no longer resolves comes back as an empty person
+	if person.IsEmpty() {
+		return exitNoMatch
+	}
+	return exitOK
+}
+
+// writePointerResult prints a person as indented JSON or as a summary.
+func writePointerResult(w io.Writer, person *pipl.Person, asJSON bool) error {
+	if asJSON {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(person)
+	}
+	out := bufio.NewWriter(w)
+	writePerson(out, person)
+	return out.Flush()
+}
@@ -0,0 +1,204 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/xpcmdshell/pipl"
+)
+
+// stringList is a flag that can be repeated, e.g. -email a -email b.
+type stringList []string
+
+func (list *stringList) String() string {
+	return strings.Join(*list, ", ")
+}
+
+func (list *stringList) Set(value string) error {
+	*list = append(*list, value)
+	return nil
+}
+
+// searchTerms holds the search term flags.
+type searchTerms struct {
+	names, emails, phones, usernames, userIDs, urls, addresses stringList
+	first, middle, last, dob                                   string
+}
+
+func (terms *searchTerms) register(flags *flag.FlagSet) {
+	flags.Var(&terms.names, "name", "full `name` (repeatable)")
+	flags.StringVar(&terms.first, "first", "", "first name")
+	flags.StringVar(&terms.middle, "middle", "", "middle name")
+	flags.StringVar(&terms.last, "last", "", "last name")
+	flags.Var(&terms.emails, "email", "email `address` (repeatable)")
+	flags.Var(&terms.phones, "phone", "phone `number`, e.g. +1 978-555-0145 (repeatable)")
+	flags.Var(&terms.usernames, "username", "`username` (repeatable)")
+	flags.Var(&terms.userIDs, "user-id", "user `ID`, e.g. 11231@facebook (repeatable)")
+	flags.Var(&terms.urls, "url", "profile `URL` (repeatable)")
+	flags.Var(&terms.addresses, "address", "full `address` (repeatable)")
+	flags.StringVar(&terms.dob, "dob", "", "date of birth, `YYYY-MM-DD`")
+}
+
+// searchObject builds the search object out of the flags.
+func (terms *searchTerms) searchObject() *pipl.Person {
+	person := pipl.NewPerson()
+	for _, name := range terms.names {
+		person.AddNameRaw(name)
+	}
+	if terms.first != "" || terms.middle != "" || terms.last != "" {
+		person.AddName(terms.first, terms.middle, terms.last, "", "")
+	}
+	for _, email := range terms.emails {
+		person.AddEmail(email)
+	}
+	for _, phone := range terms.phones {
+		person.Phones = append(person.Phones, pipl.Phone{Raw: phone})
+	}
+	for _, username := range terms.usernames {
+		person.AddUsername(username)
+	}
+	for _, userID := range terms.userIDs {
+		person.AddUserID(userID)
+	}
+	for _, url := range terms.urls {
+		person.AddURL(url)
+	}
+	for _, address := range terms.addresses {
+		person.AddAddressRaw(address)
+	}
+	if terms.dob != "" {
+		person.SetDateOfBirth(terms.dob)
+	}
+	return person
+}
+
+// showSources maps the values of -show-sources to source levels.
+var showSources = map[string]pipl.SourceLevel{
+	"none":     pipl.ShowSourcesNone,
+	"false":    pipl.ShowSourcesNone,
+	"all":      pipl.ShowSourcesAll,
+	"matching": pipl.ShowSourcesMatching,
+	"true":     pipl.ShowSourcesMatching,
+}
+
+// clientFlags holds the flags configuring the client: the config file and
+// every search parameter.
+type clientFlags struct {
+	config                     string
+	minimumProbability         float64
+	minimumMatch               float64
+	inferPersons               bool
+	showSources                string
+	hideSponsored              bool
+	liveFeeds                  bool
+	matchRequirements          string
+	sourceCategoryRequirements string
+	strict                     bool
+}
+
+func (settings *clientFlags) register(flags *flag.FlagSet) {
+	defaults := pipl.NewClient("").SearchParameters
+	flags.StringVar(&settings.config, "config", defaultConfigPath(), "config `file` holding the API key")
+	flags.Float64Var(&settings.minimumProbability, "minimum-probability", float32Default(defaults.MinimumProbability), "minimum `probability` of inferred data")
+	flags.Float64Var(&settings.minimumMatch, "minimum-match", float32Default(defaults.MinimumMatch), "minimum match `confidence` of possible persons")
+	flags.BoolVar(&settings.inferPersons, "infer-persons", defaults.InferPersons, "return persons inferred by statistical analysis")
+	flags.StringVar(&settings.showSources, "show-sources", "none", "sources to return: none, matching or all")
+	flags.BoolVar(&settings.hideSponsored, "hide-sponsored", defaults.HideSponsored, "omit sponsored data")
+	flags.BoolVar(&settings.liveFeeds, "live-feeds", defaults.LiveFeeds, "use live data sources")
+	flags.StringVar(&settings.matchRequirements, "match-requirements", "", "match `criteria`, e.g. \"name and phone\"")
+	flags.StringVar(&settings.sourceCategoryRequirements, "source-category-requirements", "", "required source `categories`")
+	flags.BoolVar(&settings.strict, "strict", false, "warn about attributes this tool doesn't know about")
+}
+
+// float32Default converts a float32 default to the float64 with the same
+// shortest representation, so -h shows 0.9 rather than 0.8999999761581421.
+func float32Default(value float32) float64 {
+	converted, _ := strconv.ParseFloat(strconv.FormatFloat(float64(value), 'f', -1, 32), 64)
+	return converted
+}
+
+// apply copies the search parameter flags into parameters.
+func (settings *clientFlags) apply(parameters *pipl.SearchParameters) error {
+	level, ok := showSources[settings.showSources]
+	if !ok {
+		return fmt.Errorf("invalid -show-sources %q: must be none, matching or all", settings.showSources)
+	}
+	parameters.MinimumProbability = float32(settings.minimumProbability)
+	parameters.MinimumMatch = float32(settings.minimumMatch)
+	parameters.InferPersons = settings.inferPersons
+	parameters.ShowSources = level
+	parameters.HideSponsored = settings.hideSponsored
+	parameters.LiveFeeds = settings.liveFeeds
+	parameters.MatchRequirements = pipl.MatchRequirements(settings.matchRequirements)
+	parameters.SourceCategoryRequirements = pipl.SourceCategoryRequirements(settings.sourceCategoryRequirements)
+	return nil
+}
+
+// client builds a client out of the flags.
+func (settings *clientFlags) client(stderr io.Writer) (*pipl.Client, error) {
+	if err := settings.apply(new(pipl.SearchParameters)); err != nil {
+		return nil, err
+	}
+	key, err := loadAPIKey(settings.config)
+	if err != nil {
+		return nil, err
+	}
+	client := pipl.NewClient(key)
+	settings.apply(client.SearchParameters)
+	if settings.strict {
+		client.StrictMode = true
+		client.OnSchemaDrift = func(drift *pipl.ErrSchemaDrift) {
+			fmt.Fprintf(stderr, "warning: %v\n", drift)
+		}
+	}
+	return client, nil
+}
+
+// writeResult prints a response as indented JSON or as a summary.
+func writeResult(w io.Writer, response *pipl.Response, asJSON bool) error {
+	if asJSON {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(response)
+	}
+	return writeSummary(w, response)
+}
+
+func runSearch(args []string, stdout io.Writer, stderr io.Writer) int {
+	flags := flag.NewFlagSet("pipl search", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	var terms searchTerms
+	var settings clientFlags
+	terms.register(flags)
+	settings.register(flags)
+	asJSON := flags.Bool("json", false, "print the raw response as JSON")
+	if err := flags.Parse(args); err != nil {
+		return exitUsage
+	}
+	if flags.NArg() > 0 {
+		fmt.Fprintf(stderr, "pipl search: unexpected argument %q\n", flags.Arg(0))
+		return exitUsage
+	}
+	client, err := settings.client(stderr)
+	if err != nil {
+		fmt.Fprintf(stderr, "pipl search: %v\n", err)
+		return exitError
+	}
+	response, err := client.SearchByPerson(terms.searchObject())
+	if err == nil {
+		err = response.Err()
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "pipl search: %v\n", err)
+		return exitError
+	}
+	if err := writeResult(stdout, response, *asJSON); err != nil {
+		fmt.Fprintf(stderr, "pipl search: %v\n", err)
+		return exitError
+	}
+	return exitCode(response)
+}
@@ -0,0 +1,417 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xpcmdshell/pipl"
+)
+
+// shellHelp lists the shell commands.
+const shellHelp = `Commands:
+  add <term> <value>      add a search term: name, first, middle, last, email, phone,
+                          username, user-id, url, address or dob
+  rm <term> [value|n]     remove a term: every value, a value, or the n-th value
+  clear                   remove every term
+  query                   show the search terms
+  set <parameter> <value> set a search parameter, e.g. set match-requirements phone
+  params                  show the search parameters
+  search                  run the search
+  open <n>                show possible person n, fetching its full profile
+  sources                 show the sources of the last search (or opened person)
+  json                    print the last result as JSON
+  history                 list the commands run so far
+  save <file>             save the session transcript as JSON
+  help                    show this help
+  quit                    leave the shell
+`
+
+// historyEntry records a command and its outcome in the transcript.
+type historyEntry struct {
+	Time     time.Time      `json:"time"`
+	Command  string         `json:"command"`
+	Query    *pipl.Person   `json:"query,omitempty"`
+	Response *pipl.Response `json:"response,omitempty"`
+	Person   *pipl.Person   `json:"person,omitempty"`
+	Error    string         `json:"error,omitempty"`
+}
+
+// transcript is the content of a saved session. Parameters holds the search
+// parameter flags, which leave the API key out.
+type transcript struct {
+	Parameters map[string]string `json:"parameters"`
+	Query      *pipl.Person      `json:"query"`
+	History    []historyEntry    `json:"history"`
+}
+
+// session is the state of an interactive shell.
+type session struct {
+	searcher   pipl.Searcher
+	parameters *pipl.SearchParameters
+	flags      *flag.FlagSet
+	settings   *clientFlags
+	terms      searchTerms
+	response   *pipl.Response
+	person     *pipl.Person
+	history    []historyEntry
+	out        io.Writer
+}
+
+func runShell(args []string, stdout io.Writer, stderr io.Writer) int {
+	flags := flag.NewFlagSet("pipl shell", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	settings := new(clientFlags)
+	settings.register(flags)
+	if err := flags.Parse(args); err != nil {
+		return exitUsage
+	}
+	client, err := settings.client(stderr)
+	if err != nil {
+		fmt.Fprintf(stderr, "pipl shell: %v\n", err)
+		return exitError
+	}
+	flags.SetOutput(ioutil.Discard)
+	shell := &session{searcher: client, parameters: client.SearchParameters, flags: flags, settings: settings, out: stdout}
+	if err := shell.run(os.Stdin); err != nil {
+		fmt.Fprintf(stderr, "pipl shell: %v\n", err)
+		return exitError
+	}
+	return exitOK
+}
+
+// run reads and executes commands until the input ends or the user quits.
+func (shell *session) run(in io.Reader) error {
+	fmt.Fprintln(shell.out, "Type 'help' for the list of commands.")
+	scanner := bufio.NewScanner(in)
+	for {
+		fmt.Fprint(shell.out, "pipl> ")
+		if !scanner.Scan() {
+			fmt.Fprintln(shell.out)
+			return scanner.Err()
+		}
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if line == "quit" || line == "exit" {
+			return nil
+		}
+		if err := shell.execute(line); err != nil {
+			fmt.Fprintf(shell.out, "error: %v\n", err)
+		}
+	}
+}
+
+// execute runs a single command line.
+func (shell *session) execute(line string) error {
+	fields := strings.Fields(line)
+	name, args := fields[0], fields[1:]
+	rest := func(n int) string {
+		// the arguments from the n-th on, with their original spacing
+		value := strings.TrimSpace(line[len(fields[0]):])
+		for i := 0; i < n; i++ {
+			value = strings.TrimSpace(value[len(args[i]):])
+		}
+		return value
+	}
+	switch name {
+	case "help":
+		fmt.Fprint(shell.out, shellHelp)
+		return nil
+	case "query":
+		shell.writeQuery()
+		return nil
+	case "params":
+		parameters := shell.settingValues()
+		names := make([]string, 0, len(parameters))
+		for name := range parameters {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			fmt.Fprintf(shell.out, "  %-29s %s\n", name, parameters[name])
+		}
+		return nil
+	case "history":
+		for i, entry := range shell.history {
+			outcome := firstNonEmpty(entry.Error, "ok")
+			fmt.Fprintf(shell.out, "%3d  %s  %-30s %s\n", i+1, entry.Time.Format("15:04:05"), entry.Command, outcome)
+		}
+		return nil
+	case "json":
+		var result interface{} = shell.person
+		if shell.person == nil {
+			result = shell.response
+		}
+		if result == (*pipl.Person)(nil) || result == (*pipl.Response)(nil) {
+			return errors.New("nothing to print, run a search first")
+		}
+		encoder := json.NewEncoder(shell.out)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(result)
+	case "sources":
+		return shell.writeSources()
+	case "save":
+		if len(args) < 1 {
+			return errors.New("usage: save <file>")
+		}
+		if err := shell.save(rest(0)); err != nil {
+			return err
+		}
+		fmt.Fprintf(shell.out, "Saved %d commands to %s.\n", len(shell.history), rest(0))
+		return nil
+	}
+
+	entry := historyEntry{Time: time.Now(), Command: line}
+	err := shell.mutate(name, args, rest, &entry)
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	shell.history = append(shell.history, entry)
+	return err
+}
+
+// mutate runs the commands that change the session, which are recorded in the
+// history.
+func (shell *session) mutate(name string, args []string, rest func(int) string, entry *historyEntry) error {
+	switch name {
+	case "add":
+		if len(args) < 2 {
+			return errors.New("usage: add <term> <value>")
+		}
+		if err := shell.terms.add(args[0], rest(1)); err != nil {
+			return err
+		}
+		shell.writeQuery()
+	case "rm":
+		if len(args) < 1 {
+			return errors.New("usage: rm <term> [value|n]")
+		}
+		if err := shell.terms.remove(args[0], rest(1)); err != nil {
+			return err
+		}
+		shell.writeQuery()
+	case "clear":
+		shell.terms = searchTerms{}
+	case "set":
+		if len(args) < 2 {
+			return errors.New("usage: set <parameter> <value>")
+		}
+		if _, ok := shell.settingValues()[args[0]]; !ok {
+			return fmt.Errorf("unknown parameter %q, see 'params'", args[0])
+		}
+		previous := shell.flags.Lookup(args[0]).Value.String()
+		if err := shell.flags.Set(args[0], rest(1)); err != nil {
+			return err
+		}
+		if err := shell.settings.apply(shell.parameters); err != nil {
+			shell.flags.Set(args[0], previous)
+			return err
+		}
+	case "search":
+		entry.Query = shell.terms.searchObject()
+		response, err := shell.searcher.SearchByPerson(entry.Query)
+		if response == nil {
+			return err
+		}
+		entry.Response = response
+		// An API error keeps the previous result open
+		if apiErr := response.Err(); apiErr != nil {
+			return apiErr
+		}
+		shell.response, shell.person = response, nil
+		if summaryErr := writeSummary(shell.out, response); err == nil {
+			err = summaryErr
+		}
+		return err
+	case "open":
+		if len(args) != 1 {
+			return errors.New("usage: open <n>")
+		}
+		return shell.open(args[0], entry)
+	default:
+		return fmt.Errorf("unknown command %q, see 'help'", name)
+	}
+	return nil
+}
+
+// open shows the n-th person of the last search, fetching the full profile of
+// possible persons through their search pointer.
+func (shell *session) open(index string, entry *historyEntry) error {
+	if shell.response == nil {
+		return errors.New("run a search first")
+	}
+	persons := shell.response.AllPersons()
+	n, err := strconv.Atoi(index)
+	if err != nil || n < 1 || n > len(persons) {
+		return fmt.Errorf("there are %d persons to choose from", len(persons))
+	}
+	person := persons[n-1]
+	if !shell.response.HasFullProfile() {
+		if person.SearchPointer == "" {
+			return errors.New("this person has no search pointer")
+		}
+		if person, err = shell.searcher.SearchByPointer(person.SearchPointer); person == nil {
+			return err
+		}
+	}
+	shell.person, entry.Person = person, person
+	return writePointerResult(shell.out, person, false)
+}
+
+// writeQuery prints the search terms.
+func (shell *session) writeQuery() {
+	lines := shell.terms.describe()
+	if len(lines) == 0 {
+		fmt.Fprintln(shell.out, "The query is empty, see 'add'.")
+	}
+	for _, line := range lines {
+		fmt.Fprintf(shell.out, "  %s\n", line)
+	}
+}
+
+// writeSources prints the sources of the last search, limited to the opened
+// person if there is one.
+func (shell *session) writeSources() error {
+	if shell.response == nil {
+		return errors.New("run a search first")
+	}
+	sources := shell.response.Sources
+	if shell.person != nil && shell.person.ID != "" {
+		sources = sources.ForPerson(shell.person.ID)
+	}
+	if len(sources) == 0 {
+		fmt.Fprintln(shell.out, "No sources. Use 'set show-sources all' and search again.")
+		return nil
+	}
+	for _, source := range sources.SortByMatch() {
+		flags := ""
+		if source.Premium {
+			flags += " premium"
+		}
+		if source.Sponsored {
+			flags += " sponsored"
+		}
+		fmt.Fprintf(shell.out, "  %-20s %-28s %s%s\n", firstNonEmpty(source.Name, source.Domain), source.Category, source.OriginURL, flags)
+	}
+	return nil
+}
+
+// save writes the transcript of the session to path.
+func (shell *session) save(path string) error {
+	saved := transcript{Parameters: shell.settingValues(), Query: shell.terms.searchObject(), History: shell.history}
+	data, err := json.MarshalIndent(saved, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, append(data, '\n'), 0600)
+}
+
+// settingValues returns the search parameter flags by name, leaving out the
+// flags that only matter when building the client.
+func (shell *session) settingValues() map[string]string {
+	values := make(map[string]string)
+	shell.flags.VisitAll(func(parameter *flag.Flag) {
+		if parameter.Name != "config" && parameter.Name != "strict" {
+			values[parameter.Name] = parameter.Value.String()
+		}
+	})
+	return values
+}
+
+// list returns the repeatable term with the given flag name, or nil.
+func (terms *searchTerms) list(term string) *stringList {
+	switch term {
+	case "name":
+		return &terms.names
+	case "email":
+		return &terms.emails
+	case "phone":
+		return &terms.phones
+	case "username":
+		return &terms.usernames
+	case "user-id":
+		return &terms.userIDs
+	case "url":
+		return &terms.urls
+	case "address":
+		return &terms.addresses
+	}
+	return nil
+}
+
+// single returns the single-valued term with the given flag name, or nil.
+func (terms *searchTerms) single(term string) *string {
+	switch term {
+	case "first":
+		return &terms.first
+	case "middle":
+		return &terms.middle
+	case "last":
+		return &terms.last
+	case "dob":
+		return &terms.dob
+	}
+	return nil
+}
+
+// add adds a value to a term, replacing the value of single-valued terms.
+func (terms *searchTerms) add(term string, value string) error {
+	if list := terms.list(term); list != nil {
+		return list.Set(value)
+	}
+	if single := terms.single(term); single != nil {
+		*single = value
+		return nil
+	}
+	return fmt.Errorf("unknown term %q", term)
+}
+
+// remove removes every value of a term, a given value, or the n-th value.
+func (terms *searchTerms) remove(term string, value string) error {
+	if single := terms.single(term); single != nil {
+		*single = ""
+		return nil
+	}
+	list := terms.list(term)
+	if list == nil {
+		return fmt.Errorf("unknown term %q", term)
+	}
+	if value == "" {
+		*list = nil
+		return nil
+	}
+	for i, existing := range *list {
+		if existing == value || strconv.Itoa(i+1) == value {
+			*list = append((*list)[:i], (*list)[i+1:]...)
+			return nil
+		}
+	}
+	return fmt.Errorf("no %s %q", term, value)
+}
+
+// describe lists the terms as "term: value" lines.
+func (terms *searchTerms) describe() []string {
+	var lines []string
+	for _, term := range []string{"name", "first", "middle", "last", "email", "phone", "username", "user-id", "url", "address", "dob"} {
+		if single := terms.single(term); single != nil && *single != "" {
+			lines = append(lines, term+": "+*single)
+		}
+		if list := terms.list(term); list != nil {
+			for i, value := range *list {
+				lines = append(lines, fmt.Sprintf("%s %d: %s", term, i+1, value))
+			}
+		}
+	}
+	return lines
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/xpcmdshell/pipl"
+)
+
+// shellSearcher answers every search with the same response, and every search
+// pointer with the same person. It records what it was asked.
+type shellSearcher struct {
+	response *pipl.Response
+	person   *pipl.Person
+	query    *pipl.Person
+	pointer  string
+}
+
+func (searcher *shellSearcher) SearchByPerson(searchObject *pipl.Person) (*pipl.Response, error) {
+	searcher.query = searchObject
+	return searcher.response, nil
+}
+
+func (searcher *shellSearcher) SearchByPointer(searchPointer string) (*pipl.Person, error) {
+	searcher.pointer = searchPointer
+	return searcher.person, nil
+}
+
+func TestShell(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pipl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	searcher := &shellSearcher{
+		response: loadResponse(t, "possible_persons_response.json"),
+		person:   &loadResponse(t, "person_response.json").Person,
+	}
+	flags := flag.NewFlagSet("pipl shell", flag.ContinueOnError)
+	flags.SetOutput(ioutil.Discard)
+	settings := new(clientFlags)
+	settings.register(flags)
+	parameters := pipl.NewClient("secret").SearchParameters
+	var out bytes.Buffer
+	shell := &session{searcher: searcher, parameters: parameters, flags: flags, settings: settings, out: &out}
+
+	transcriptPath := filepath.Join(dir, "session.json")
+	script := strings.Join([]string{
+		"add name Clark  Kent",
+		"add email clark@example.com",
+		"add email kent@example.com",
+		"rm email 1",
+		"set show-sources all",
+		"set show-sources everything",
+		"frobnicate",
+		"search",
+		"open 2",
+		"save " + transcriptPath,
+		"quit",
+		"search",
+	}, "\n")
+	if err := shell.run(strings.NewReader(script)); err != nil {
+		t.Fatal(err)
+	}
+	output := out.String()
+
+	if len(searcher.query.Names) != 1 || searcher.query.Names[0].Raw != "Clark  Kent" ||
+		len(searcher.query.Emails) != 1 || searcher.query.Emails[0].Address != "kent@example.com" {
+		t.Errorf("unexpected query: %+v", searcher.query)
+	}
+	if parameters.ShowSources != pipl.ShowSourcesAll {
+		t.Errorf("expected the search parameters to follow set, got %q", parameters.ShowSources)
+	}
+	if !strings.Contains(output, "error: invalid -show-sources") || !strings.Contains(output, `error: unknown command "frobnicate"`) {
+		t.Errorf("expected errors in the output:\n%s", output)
+	}
+	if searcher.pointer != searcher.response.PossiblePersons[1].SearchPointer || !strings.Contains(output, "Field Reporter") {
+		t.Errorf("expected the second possible person to be opened, got pointer %q:\n%s", searcher.pointer, output)
+	}
+
+	data, err := ioutil.ReadFile(transcriptPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(data, []byte("secret")) {
+		t.Error("the transcript holds the API key")
+	}
+	var saved transcript
+	if err := json.Unmarshal(data, &saved); err != nil {
+		t.Fatal(err)
+	}
+	if len(saved.History) != 9 || saved.History[5].Error == "" || saved.History[7].Response == nil ||
+		saved.History[8].Person == nil || saved.Parameters["show-sources"] != "all" {
+		t.Errorf("unexpected transcript:\n%s", data)
+	}
+}
+
+func TestShellSearchError(t *testing.T) {
+	possible := loadResponse(t, "possible_persons_response.json")
+	searcher := &shellSearcher{response: possible, person: &loadResponse(t, "person_response.json").Person}
+	var out bytes.Buffer
+	shell := &session{searcher: searcher, parameters: pipl.NewClient("secret").SearchParameters, out: &out}
+
+	if err := shell.run(strings.NewReader("add email kent@example.com\nsearch\n")); err != nil {
+		t.Fatal(err)
+	}
+	searcher.response = loadResponse(t, "error_response.json")
+	if err := shell.run(strings.NewReader("search\nopen 2\n")); err != nil {
+		t.Fatal(err)
+	}
+	output := out.String()
+	if !strings.Contains(output, "error: The API key is invalid") || strings.Contains(output, "No match.") {
+		t.Errorf("expected the API error in the output:\n%s", output)
+	}
+	if shell.response != possible || searcher.pointer != possible.PossiblePersons[1].SearchPointer {
+		t.Errorf("expected the previous result to be kept, got pointer %q:\n%s", searcher.pointer, output)
+	}
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/xpcmdshell/pipl"
+)
+
+// writeSummary prints a short, readable account of a response: the full
+// profile, or the possible persons with their search pointers.
+func writeSummary(w io.Writer, response *pipl.Response) error {
+	out := bufio.NewWriter(w)
+	for _, warning := range response.Warnings {
+		fmt.Fprintf(out, "warning: %s\n", warning)
+	}
+	switch err := response.Err(); {
+	case err != nil:
+		fmt.Fprintf(out, "Error: %v\n", err)
+	case response.IsEmpty():
+		fmt.Fprintln(out, "No match.")
+	case response.HasFullProfile():
+		writePerson(out, &response.Person)
+	default:
+		fmt.Fprintf(out, "%d possible persons. Use 'pipl pointer <search pointer>' for a full profile.\n", len(response.PossiblePersons))
+		for i := range response.PossiblePersons {
+			fmt.Fprintf(out, "\n[%d] ", i+1)
+			writePerson(out, &response.PossiblePersons[i])
+		}
+	}
+	if response.SearchID != "" {
+		fmt.Fprintf(out, "\nSearch ID: %s\n", response.SearchID)
+	}
+	return out.Flush()
+}
+
+// writePerson prints the main fields of a person.
+func writePerson(out *bufio.Writer, person *pipl.Person) {
+	var names []string
+	for _, name := range person.Names {
+		names = append(names, firstNonEmpty(name.Display, strings.TrimSpace(name.First+" "+name.Last), name.Raw))
+	}
+	fmt.Fprintln(out, firstNonEmpty(strings.Join(names, " / "), "(no name)"))
+	if person.Match != 0 {
+		fmt.Fprintf(out, "  Match:      %.2f\n", person.Match)
+	}
+	if person.SearchPointer != "" {
+		fmt.Fprintf(out, "  Pointer:    %s\n", person.SearchPointer)
+	}
+	var values []string
+	for _, email := range person.Emails {
+		values = append(values, firstNonEmpty(email.Address, email.AddressMD5))
+	}
+	writeValues(out, "Emails", values)
+	values = nil
+	for _, phone := range person.Phones {
+		values = append(values, firstNonEmpty(phone.DisplayInternational, phone.Display, phone.Raw))
+	}
+	writeValues(out, "Phones", values)
+	values = nil
+	for _, username := range person.Usernames {
+		values = append(values, username.Content)
+	}
+	writeValues(out, "Usernames", values)
+	values = nil
+	for _, address := range person.Addresses {
+		values = append(values, firstNonEmpty(address.Display, address.Raw))
+	}
+	writeValues(out, "Addresses", values)
+	values = nil
+	for _, job := range person.Jobs {
+		values = append(values, firstNonEmpty(job.Display, job.Title+" "+job.Organization))
+	}
+	writeValues(out, "Jobs", values)
+	values = nil
+	for _, education := range person.Educations {
+		values = append(values, firstNonEmpty(education.Display, education.Degree+" "+education.School))
+	}
+	writeValues(out, "Education", values)
+	if person.DateOfBirth != nil && person.DateOfBirth.Display != "" {
+		writeValues(out, "Age", []string{person.DateOfBirth.Display})
+	}
+	values = nil
+	for _, url := range person.URLs {
+		values = append(values, url.URL)
+	}
+	writeValues(out, "URLs", values)
+	values = nil
+	for _, relationship := range person.Relationships {
+		name := ""
+		if len(relationship.Names) > 0 {
+			name = firstNonEmpty(relationship.Names[0].Display, strings.TrimSpace(relationship.Names[0].First+" "+relationship.Names[0].Last))
+		}
+		values = append(values, fmt.Sprintf("%s (%s)", name, firstNonEmpty(string(relationship.Subtype), string(relationship.Type))))
+	}
+	writeValues(out, "Relatives", values)
+}
+
+// writeValues prints a labelled list of values, one per line.
+func writeValues(out *bufio.Writer, label string, values []string) {
+	heading := label + ":"
+	for _, value := range values {
+		if strings.TrimSpace(value) == "" {
+			continue
+		}
+		fmt.Fprintf(out, "  %-11s %s\n", heading, value)
+		heading = ""
+	}
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if strings.TrimSpace(value) != "" {
+			return value
+		}
+	}
+	return ""
+}
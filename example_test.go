@@ -39,32 +39,31 @@ func Example() {
 		os.Exit(1)
 	}
 
-	// When multiple PossiblePersons are returned, we get a "preview" of each of
-	// each of them (< 100% match confidence)
-	if results.PersonsCount > 1 {
-		for _, person := range results.PossiblePersons {
-			// In order to get the full info on each, we need to a follow up query
-			// to pull a full person profile by search pointer
-			searchPtr := person.SearchPointer
-			ptrResults, err := client.SearchByPointer(searchPtr)
+	if results.IsEmpty() {
+		fmt.Println("No results!")
+	}
+	for _, person := range results.AllPersons() {
+		// When multiple PossiblePersons are returned, we get a "preview" of each of
+		// them (< 100% match confidence). In order to get the full info on each, we
+		// need a follow up query to pull a full person profile by search pointer.
+		// When a single result is returned from our search, we get a full profile
+		// by default (100% match confidence)
+		if results.IsAmbiguous() {
+			person, err = client.SearchByPointer(person.SearchPointer)
 			if err != nil {
 				log.Println(err)
 				os.Exit(1)
 			}
-			for _, addr := range ptrResults.Addresses {
-				// Print out addresses associated with search target, for the sake of example.
-				fmt.Println(addr)
-			}
 		}
-	} else if results.PersonsCount == 1 {
-		// When a single result is returned from our search, we get a full
-		// profile by default (100% match confidence)
-		for _, addr := range results.Person.Addresses {
+		for _, addr := range person.Addresses {
 			// Print out addresses associated with search target, for the sake of example.
 			fmt.Println(addr)
 		}
-	} else {
-		fmt.Println("No results!")
+	}
+
+	// Or print a readable report of everything found, in text or Markdown
+	if err := results.WriteReport(os.Stdout, pipl.ReportMarkdown); err != nil {
+		log.Println(err)
 	}
 	log.Println("Shutting down.")
 }
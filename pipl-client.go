@@ -1,11 +1,14 @@
 package pipl
 
 import (
+	"context"
 	"encoding/json"
 	"io/ioutil"
 	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
+	"time"
 )
 
 // SourceLevel is used internally to represent the possible values
@@ -27,6 +30,9 @@ const (
 	// PiplAPIEndpoint is where we POST queries to
 	PiplAPIEndpoint string = "https://api.pipl.com/search/"
 
+	// PiplThumbnailEndpoint is where we GET image thumbnails from
+	PiplThumbnailEndpoint string = "https://thumb.pipl.com/image"
+
 	// ShowSourcesNone specifies that we don't need source info back with search results
 	ShowSourcesNone SourceLevel = "false"
 
@@ -50,6 +56,44 @@ type Client struct {
 	// Parameters contains the search parameters that are submitted with your query,
 	// which may affect the data returned
 	SearchParameters *SearchParameters
+
+	// ThumbnailEndpoint is the URL of Pipl's thumbnail service. It defaults to
+	// PiplThumbnailEndpoint, and can be pointed elsewhere for testing.
+	ThumbnailEndpoint string
+
+	// StrictMode makes the client check every decoded response for attributes
+	// and enumeration values that this package doesn't model (schema drift).
+	// Drift is reported to OnSchemaDrift; it doesn't fail the request unless
+	// FailOnSchemaDrift is also set.
+	StrictMode bool
+
+	// OnSchemaDrift is called in strict mode whenever a response contains drift
+	OnSchemaDrift func(drift *ErrSchemaDrift)
+
+	// FailOnSchemaDrift makes searches return an *ErrSchemaDrift (along with the
+	// decoded results) when strict mode detects drift
+	FailOnSchemaDrift bool
+
+	// Limiter, if set, is waited on before every request (searches and
+	// thumbnails alike) to stay within the account's rate limits
+	Limiter Limiter
+
+	// MaxRetries is the number of times a request is retried after a network
+	// error, a 429 (rate limited) or a 5xx status. Zero disables retries.
+	MaxRetries int
+
+	// RetryBackoff is the wait before the first retry, doubled for every retry
+	// after it. It defaults to one second. A Retry-After header takes
+	// precedence.
+	RetryBackoff time.Duration
+}
+
+// Limiter paces requests. *rate.Limiter from golang.org/x/time/rate implements
+// it.
+type Limiter interface {
+	// Wait blocks until a request may be sent, or returns an error if ctx is
+	// done first
+	Wait(ctx context.Context) error
 }
 
 // SearchParameters holds options that can affect data returned by a search.
@@ -93,6 +137,7 @@ type SearchParameters struct {
 func NewClient(APIKey string) (client *Client) {
 	piplClient := new(Client)
 	piplClient.HTTPClient = new(http.Client)
+	piplClient.ThumbnailEndpoint = PiplThumbnailEndpoint
 	piplClient.SearchParameters = new(SearchParameters)
 	piplClient.SearchParameters.APIKey = APIKey
 	piplClient.SearchParameters.MinimumProbability = 0.9
@@ -161,26 +206,51 @@ func meetsMinimumCriteria(searchObject *Person) bool {
 	return false
 }
 
+// values returns the search parameters as sent to the API, leaving out the
+// API key.
+func (parameters *SearchParameters) values() url.Values {
+	values := url.Values{}
+	if parameters.ShowSources != ShowSourcesNone {
+		values.Add("show_sources", string(parameters.ShowSources))
+	}
+	if parameters.MatchRequirements != MatchRequirementsNone {
+		values.Add("match_requirements", string(parameters.MatchRequirements))
+	}
+	if parameters.SourceCategoryRequirements != SourceCategoryRequirementsNone {
+		values.Add("source_category_requirements", string(parameters.SourceCategoryRequirements))
+	}
+	if parameters.MinimumProbability != 0 {
+		values.Add("minimum_probability", strconv.FormatFloat(float64(parameters.MinimumProbability), 'f', -1, 32))
+	}
+	if parameters.MinimumMatch != 0 {
+		values.Add("minimum_match", strconv.FormatFloat(float64(parameters.MinimumMatch), 'f', -1, 32))
+	}
+	if parameters.InferPersons {
+		values.Add("infer_persons", "true")
+	}
+	if parameters.HideSponsored {
+		values.Add("hide_sponsored", "true")
+	}
+	values.Add("live_feeds", strconv.FormatBool(parameters.LiveFeeds))
+	return values
+}
+
 // SearchByPerson takes a person object (filled with search terms) and returns the
 // results in the form of a Response struct. If successful, the response struct
 // will contains the results, and err will be nil. If an error occurs, the struct pointer
-// will be nil and you should check err for additional information.
+// will be nil and you should check err for additional information. The exceptions
+// are an *ErrAPI when the Pipl service answers with an error (e.g. an invalid key
+// or an exceeded quota), and an *ErrSchemaDrift in strict mode, both of which are
+// returned alongside the response.
 func (searchClient *Client) SearchByPerson(searchObject *Person) (*Response, error) {
 	if !meetsMinimumCriteria(searchObject) {
 		return nil, &ErrInsufficientSearch{}
 	}
-	postData := url.Values{}
-	postData.Add("key", searchClient.SearchParameters.APIKey)
-
-	if searchClient.SearchParameters.ShowSources != ShowSourcesNone {
-		postData.Add("show_sources", string(searchClient.SearchParameters.ShowSources))
-	}
-	if searchClient.SearchParameters.MatchRequirements != MatchRequirementsNone {
-		postData.Add("match_requirements", string(searchClient.SearchParameters.MatchRequirements))
-	}
-	if searchClient.SearchParameters.SourceCategoryRequirements != SourceCategoryRequirementsNone {
-		postData.Add("source_category_requirements", string(searchClient.SearchParameters.SourceCategoryRequirements))
+	if err := searchObject.Validate(); err != nil {
+		return nil, err
 	}
+	postData := searchClient.SearchParameters.values()
+	postData.Add("key", searchClient.SearchParameters.APIKey)
 	personJSON, err := json.Marshal(searchObject)
 	if err != nil {
 		return nil, err
@@ -191,24 +261,22 @@ func (searchClient *Client) SearchByPerson(searchObject *Person) (*Response, err
 		return nil, err
 	}
 	request.Header.Add("Content-Type", "application/x-www-form-urlencoded")
-	response, err := searchClient.HTTPClient.Do(request)
+	body, _, err := searchClient.do(request)
 	if err != nil {
 		return nil, err
 	}
-	body, err := ioutil.ReadAll(response.Body)
-	if err != nil {
-		return nil, err
-	}
-	piplResponse := new(Response)
-	err = json.Unmarshal(body, piplResponse)
-	if err != nil {
-		return nil, err
+	piplResponse, err := searchClient.ParseResponse(body)
+	if piplResponse != nil {
+		if apiErr := piplResponse.Err(); apiErr != nil {
+			return piplResponse, apiErr
+		}
 	}
-	return piplResponse, nil
+	return piplResponse, err
 }
 
 // SearchByPointer takes a search pointer string and returns the full
-// information for the person associated with that pointer
+// information for the person associated with that pointer. If the Pipl
+// service answers with an error, it is returned as an *ErrAPI.
 func (searchClient *Client) SearchByPointer(searchPointer string) (*Person, error) {
 	postData := url.Values{}
 	postData.Add("key", searchClient.SearchParameters.APIKey)
@@ -218,18 +286,108 @@ func (searchClient *Client) SearchByPointer(searchPointer string) (*Person, erro
 		return nil, err
 	}
 	request.Header.Add("Content-Type", "application/x-www-form-urlencoded")
-	response, err := searchClient.HTTPClient.Do(request)
+	body, _, err := searchClient.do(request)
 	if err != nil {
 		return nil, err
 	}
+	piplResponse, err := searchClient.ParseResponse(body)
+	if piplResponse == nil {
+		return nil, err
+	}
+	if apiErr := piplResponse.Err(); apiErr != nil {
+		return nil, apiErr
+	}
+	return &piplResponse.Person, err
+}
+
+// do sends a request with the client's HTTP client and returns the response
+// body along with the response itself (for its headers). Every request goes
+// through the client's Limiter, and is retried as set by MaxRetries.
+func (searchClient *Client) do(request *http.Request) ([]byte, *http.Response, error) {
+	ctx := request.Context()
+	backoff := searchClient.RetryBackoff
+	if backoff <= 0 {
+		backoff = time.Second
+	}
+	for attempt := 0; ; attempt++ {
+		if searchClient.Limiter != nil {
+			if err := searchClient.Limiter.Wait(ctx); err != nil {
+				return nil, nil, err
+			}
+		}
+		attemptRequest := request.Clone(ctx)
+		if request.GetBody != nil {
+			body, err := request.GetBody()
+			if err != nil {
+				return nil, nil, err
+			}
+			attemptRequest.Body = body
+		}
+		body, response, err := searchClient.send(attemptRequest)
+		if attempt >= searchClient.MaxRetries || !retryable(response, err) || ctx.Err() != nil {
+			return body, response, err
+		}
+		wait := backoff << uint(attempt)
+		if response != nil {
+			if seconds, err := strconv.Atoi(response.Header.Get("Retry-After")); err == nil && seconds >= 0 {
+				wait = time.Duration(seconds) * time.Second
+			}
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// send sends a single request and reads the response body.
+func (searchClient *Client) send(request *http.Request) ([]byte, *http.Response, error) {
+	response, err := searchClient.HTTPClient.Do(request)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer response.Body.Close()
 	body, err := ioutil.ReadAll(response.Body)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
+	return body, response, nil
+}
+
+// retryable reports whether a request that failed with err, or was answered
+// with response, is worth retrying.
+func retryable(response *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	return response.StatusCode == http.StatusTooManyRequests || response.StatusCode >= 500
+}
+
+// ParseResponse decodes a raw API response body. Searches use it internally, but
+// it can also be used to load recorded responses. In strict mode, drift from the
+// modelled schema is reported to OnSchemaDrift, and if FailOnSchemaDrift is set
+// the decoded response is returned along with an *ErrSchemaDrift.
+func (searchClient *Client) ParseResponse(body []byte) (*Response, error) {
 	piplResponse := new(Response)
-	err = json.Unmarshal(body, piplResponse)
+	err := json.Unmarshal(body, piplResponse)
 	if err != nil {
 		return nil, err
 	}
-	return &piplResponse.Person, nil
+	if !searchClient.StrictMode {
+		return piplResponse, nil
+	}
+	drift := DetectSchemaDrift(piplResponse)
+	if drift == nil {
+		return piplResponse, nil
+	}
+	if searchClient.OnSchemaDrift != nil {
+		searchClient.OnSchemaDrift(drift)
+	}
+	if searchClient.FailOnSchemaDrift {
+		return piplResponse, drift
+	}
+	return piplResponse, nil
 }
@@ -0,0 +1,143 @@
+package pipl_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/xpcmdshell/pipl"
+)
+
+// roundTripFunc answers HTTP requests without a network.
+type roundTripFunc func(request *http.Request) (*http.Response, error)
+
+func (answer roundTripFunc) RoundTrip(request *http.Request) (*http.Response, error) {
+	return answer(request)
+}
+
+// recordingClient returns a client whose requests are decoded into form, and
+// which answers every request with body.
+func recordingClient(t *testing.T, form *url.Values, body string) *pipl.Client {
+	client := pipl.NewClient("secret")
+	client.HTTPClient = &http.Client{Transport: roundTripFunc(func(request *http.Request) (*http.Response, error) {
+		data, err := ioutil.ReadAll(request.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if *form, err = url.ParseQuery(string(data)); err != nil {
+			t.Fatal(err)
+		}
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": {"application/json"}},
+			Body:       ioutil.NopCloser(strings.NewReader(body)),
+		}, nil
+	})}
+	return client
+}
+
+func TestSearchByPersonParameters(t *testing.T) {
+	var form url.Values
+	client := recordingClient(t, &form, `{"@http_status_code": 200, "@persons_count": 0}`)
+	searchObject := pipl.NewPerson()
+	searchObject.AddEmail("clark@example.com")
+
+	if _, err := client.SearchByPerson(searchObject); err != nil {
+		t.Fatal(err)
+	}
+	expected := url.Values{"key": {"secret"}, "minimum_probability": {"0.9"}, "live_feeds": {"true"}}
+	for name := range form {
+		if name != "person" && form.Get(name) != expected.Get(name) {
+			t.Errorf("unexpected parameter %s=%q with the defaults", name, form.Get(name))
+		}
+	}
+	if !strings.Contains(form.Get("person"), "clark@example.com") {
+		t.Errorf("unexpected person %q", form.Get("person"))
+	}
+
+	parameters := client.SearchParameters
+	parameters.MinimumProbability = 0.75
+	parameters.MinimumMatch = 0.5
+	parameters.InferPersons = true
+	parameters.HideSponsored = true
+	parameters.LiveFeeds = false
+	parameters.ShowSources = pipl.ShowSourcesAll
+	parameters.MatchRequirements = "email"
+	if _, err := client.SearchByPerson(searchObject); err != nil {
+		t.Fatal(err)
+	}
+	expected = url.Values{
+		"minimum_probability": {"0.75"},
+		"minimum_match":       {"0.5"},
+		"infer_persons":       {"true"},
+		"hide_sponsored":      {"true"},
+		"live_feeds":          {"false"},
+		"show_sources":        {"all"},
+		"match_requirements":  {"email"},
+	}
+	for name := range expected {
+		if form.Get(name) != expected.Get(name) {
+			t.Errorf("expected %s=%q, got %q", name, expected.Get(name), form.Get(name))
+		}
+	}
+}
+
+func TestSearchByPointerError(t *testing.T) {
+	var form url.Values
+	client := recordingClient(t, &form, `{"@http_status_code": 403, "@persons_count": 0, "error": "The API key is invalid"}`)
+	person, err := client.SearchByPointer("0123456789abcdef")
+	if apiErr, ok := err.(*pipl.ErrAPI); !ok || apiErr.StatusCode != 403 || person != nil {
+		t.Errorf("expected an *ErrAPI, got %v (%+v)", err, person)
+	}
+	if form.Get("search_pointer") != "0123456789abcdef" {
+		t.Errorf("unexpected search pointer %q", form.Get("search_pointer"))
+	}
+}
+
+func TestSearchByPersonError(t *testing.T) {
+	var form url.Values
+	client := recordingClient(t, &form, `{"@http_status_code": 403, "@persons_count": 0, "error": "The API key is invalid"}`)
+	searchObject := pipl.NewPerson()
+	searchObject.AddEmail("clark@example.com")
+	response, err := client.SearchByPerson(searchObject)
+	if apiErr, ok := err.(*pipl.ErrAPI); !ok || apiErr.Message != "The API key is invalid" {
+		t.Errorf("expected an *ErrAPI, got %v", err)
+	}
+	if response == nil || response.HTTPStatusCode != 403 {
+		t.Errorf("expected the response alongside the error, got %+v", response)
+	}
+}
+
+func TestSearchByPersonRetries(t *testing.T) {
+	var bodies []string
+	client := pipl.NewClient("secret")
+	client.MaxRetries = 1
+	client.RetryBackoff = time.Millisecond
+	client.HTTPClient = &http.Client{Transport: roundTripFunc(func(request *http.Request) (*http.Response, error) {
+		data, err := ioutil.ReadAll(request.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		bodies = append(bodies, string(data))
+		status, body := http.StatusTooManyRequests, `{"@http_status_code": 429, "error": "slow down"}`
+		if len(bodies) > 1 {
+			status, body = http.StatusOK, `{"@http_status_code": 200, "@persons_count": 0}`
+		}
+		return &http.Response{
+			StatusCode: status,
+			Header:     http.Header{"Content-Type": {"application/json"}},
+			Body:       ioutil.NopCloser(strings.NewReader(body)),
+		}, nil
+	})}
+	searchObject := pipl.NewPerson()
+	searchObject.AddEmail("clark@example.com")
+	if _, err := client.SearchByPerson(searchObject); err != nil {
+		t.Fatal(err)
+	}
+	if len(bodies) != 2 || bodies[0] == "" || bodies[0] != bodies[1] {
+		t.Errorf("expected the search to be sent twice with the same form, got %q", bodies)
+	}
+}
@@ -0,0 +1,207 @@
+package pipl
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// CSVOptions controls how persons are flattened into CSV rows.
+//
+// In wide mode (the default) every person is a single row, with one column per
+// value: "names" when Values is 1, or "names_1", "names_2"... up to Values per
+// category ("gender" and "dob" only ever have one). In long mode (Long) every
+// value is a row of its own, with "person", "id", "category", "index" and
+// "value" columns.
+//
+// Values are rendered in their display form where Pipl provides one. With
+// Validity, each value gets "current", "inferred", "valid_since" and
+// "last_seen" columns as well (suffixed to the value column in wide mode, e.g.
+// "names_current" or "names_1_current").
+//
+// The files are meant to be opened in spreadsheets, which run cells starting
+// with "=", "+", "-" or "@" as formulas. Such values are prefixed with "'" so
+// they're shown as text, unless AllowFormulas is set.
+type CSVOptions struct {
+	// Long selects one row per value instead of one row per person
+	Long bool
+
+	// Values is the number of values per category in wide mode (default 1)
+	Values int
+
+	// Categories restricts the export to the given categories (default all)
+	Categories []FieldCategory
+
+	// Validity adds the validity columns
+	Validity bool
+
+	// Columns selects and orders the columns, by their default names. Columns
+	// that don't exist are left empty. The default is every column.
+	Columns []string
+
+	// Headers renames columns in the header row, keyed by their default names
+	Headers map[string]string
+
+	// AllowFormulas writes values as they are, even those a spreadsheet would
+	// run as a formula
+	AllowFormulas bool
+}
+
+// validityColumns are the suffixes of the validity columns.
+var validityColumns = []string{"current", "inferred", "valid_since", "last_seen"}
+
+// WriteCSV flattens the persons of the response (see AllPersons) into CSV.
+// Options may be nil.
+func (response *Response) WriteCSV(w io.Writer, options *CSVOptions) error {
+	return WriteCSV(w, options, response.AllPersons()...)
+}
+
+// WriteCSV flattens persons into CSV, starting with a header row. Options may
+// be nil.
+func WriteCSV(w io.Writer, options *CSVOptions, persons ...*Person) error {
+	out := csv.NewWriter(w)
+	out.WriteAll(CSVRows(options, persons...))
+	return out.Error()
+}
+
+// CSVRows flattens persons into rows as WriteCSV does, starting with the header
+// row. Options may be nil.
+func CSVRows(options *CSVOptions, persons ...*Person) [][]string {
+	if options == nil {
+		options = new(CSVOptions)
+	}
+	categories := options.Categories
+	if len(categories) == 0 {
+		categories = FieldCategories
+	}
+	var columns []string
+	var records []map[string]string
+	if options.Long {
+		columns = append([]string{"person", "id", "match", "category", "index", "value"}, validityColumnNames(options, "")...)
+		for i, person := range persons {
+			for _, category := range categories {
+				for j, item := range person.PersonFields.items(category) {
+					record := map[string]string{
+						"person":   strconv.Itoa(i + 1),
+						"id":       string(person.ID),
+						"match":    formatMatch(person.Match),
+						"category": string(category),
+						"index":    strconv.Itoa(j + 1),
+						"value":    item.label(),
+					}
+					addValidity(record, options, "", item)
+					records = append(records, record)
+				}
+			}
+		}
+	} else {
+		values := options.Values
+		if values < 1 {
+			values = 1
+		}
+		columns = []string{"id", "match"}
+		for _, category := range categories {
+			count := values
+			if personFieldIndex[category].single {
+				count = 1
+			}
+			for j := 0; j < count; j++ {
+				column := wideColumn(category, j, count)
+				columns = append(columns, column)
+				columns = append(columns, validityColumnNames(options, column+"_")...)
+			}
+		}
+		for _, person := range persons {
+			record := map[string]string{"id": string(person.ID), "match": formatMatch(person.Match)}
+			for _, category := range categories {
+				count := values
+				if personFieldIndex[category].single {
+					count = 1
+				}
+				for j, item := range person.PersonFields.items(category) {
+					if j == count {
+						break
+					}
+					column := wideColumn(category, j, count)
+					record[column] = item.label()
+					addValidity(record, options, column+"_", item)
+				}
+			}
+			records = append(records, record)
+		}
+	}
+
+	if len(options.Columns) > 0 {
+		columns = options.Columns
+	}
+	header := make([]string, len(columns))
+	for i, column := range columns {
+		header[i] = firstNonEmpty(options.Headers[column], column)
+	}
+	rows := [][]string{header}
+	for _, record := range records {
+		row := make([]string, len(columns))
+		for i, column := range columns {
+			row[i] = record[column]
+			if !options.AllowFormulas {
+				row[i] = escapeFormula(row[i])
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// escapeFormula prefixes values a spreadsheet would take for a formula with
+// "'", so they're shown as text.
+func escapeFormula(value string) string {
+	if value != "" && strings.IndexByte("=+-@\t\r", value[0]) >= 0 {
+		return "'" + value
+	}
+	return value
+}
+
+// wideColumn names the column of the j-th value of a category in wide mode.
+func wideColumn(category FieldCategory, j int, count int) string {
+	if count == 1 {
+		return string(category)
+	}
+	return string(category) + "_" + strconv.Itoa(j+1)
+}
+
+// validityColumnNames returns the validity columns for a value, if enabled.
+func validityColumnNames(options *CSVOptions, prefix string) []string {
+	if !options.Validity {
+		return nil
+	}
+	names := make([]string, len(validityColumns))
+	for i, suffix := range validityColumns {
+		names[i] = prefix + suffix
+	}
+	return names
+}
+
+// addValidity fills the validity columns of a value, if enabled.
+func addValidity(record map[string]string, options *CSVOptions, prefix string, item fieldItem) {
+	validity := validityOf(item)
+	if !options.Validity || validity == nil {
+		return
+	}
+	if validity.Current {
+		record[prefix+"current"] = "true"
+	}
+	if validity.Inferred {
+		record[prefix+"inferred"] = "true"
+	}
+	record[prefix+"valid_since"] = string(validity.ValidSince)
+	record[prefix+"last_seen"] = string(validity.LastSeen)
+}
+
+// formatMatch renders a match score, or "" for persons without one.
+func formatMatch(match float32) string {
+	if match == 0 {
+		return ""
+	}
+	return strconv.FormatFloat(float64(match), 'f', -1, 32)
+}
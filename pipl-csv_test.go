@@ -0,0 +1,75 @@
+package pipl_test
+
+import (
+	"bytes"
+	"encoding/csv"
+	"reflect"
+	"testing"
+
+	"github.com/xpcmdshell/pipl"
+)
+
+func TestCSVWide(t *testing.T) {
+	response, _ := loadResponse(t, "possible_persons_response.json")
+	var buffer bytes.Buffer
+	options := &pipl.CSVOptions{Values: 2, Validity: true}
+	if err := response.WriteCSV(&buffer, options); err != nil {
+		t.Fatal(err)
+	}
+	rows, err := csv.NewReader(&buffer).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 3 {
+		t.Fatalf("expected a header and 2 rows, got %d rows", len(rows))
+	}
+	header := rows[0]
+	if header[0] != "id" || header[1] != "match" || header[2] != "names_1" || header[3] != "names_1_current" {
+		t.Errorf("unexpected header: %v", header)
+	}
+	for _, column := range header {
+		if column == "gender_1" || column == "dob_2" {
+			t.Errorf("single-valued fields should have a single column: %v", header)
+		}
+	}
+	if rows[1][1] != "0.72" {
+		t.Errorf("unexpected match %q", rows[1][1])
+	}
+}
+
+func TestCSVLongColumns(t *testing.T) {
+	response, _ := loadResponse(t, "person_response.json")
+	options := &pipl.CSVOptions{
+		Long:       true,
+		Categories: []pipl.FieldCategory{pipl.CategoryPhones},
+		Validity:   true,
+		Columns:    []string{"category", "value", "current"},
+		Headers:    map[string]string{"value": "Phone"},
+	}
+	rows := pipl.CSVRows(options, &response.Person)
+	expected := [][]string{
+		{"category", "Phone", "current"},
+		{"phones", "'+1 978-555-0145", "true"},
+		{"phones", "'+1 617-555-0123 x12", ""},
+	}
+	if !reflect.DeepEqual(rows, expected) {
+		t.Errorf("unexpected rows: %q", rows)
+	}
+
+	options.AllowFormulas = true
+	if rows := pipl.CSVRows(options, &response.Person); rows[1][1] != "+1 978-555-0145" {
+		t.Errorf("AllowFormulas should keep values as they are, got %q", rows[1][1])
+	}
+}
+
+func TestCSVFormulaInjection(t *testing.T) {
+	person := pipl.NewPerson()
+	person.AddNameRaw(`=HYPERLINK("http://example.com","Clark")`)
+	person.AddURL("@SUM(1+1)")
+	person.AddEmail("clark@example.com")
+	rows := pipl.CSVRows(&pipl.CSVOptions{Columns: []string{"names", "urls", "emails"}}, person)
+	expected := []string{`'=HYPERLINK("http://example.com","Clark")`, "'@SUM(1+1)", "clark@example.com"}
+	if !reflect.DeepEqual(rows[1], expected) {
+		t.Errorf("unexpected row: %q", rows[1])
+	}
+}
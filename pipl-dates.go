@@ -0,0 +1,137 @@
+package pipl
+
+import (
+	"fmt"
+	"time"
+)
+
+// Date holds a date as returned by the Pipl API. Depending on the source, Pipl
+// reports either a plain date ("2012-03-01"), a timestamp ("2012-03-01T14:22:05"),
+// or only a year or month ("2012", "2012-03"). Date keeps the original string,
+// so values survive a JSON round trip unchanged. Use Parse, Time and Precision to
+// work with the value as a time.Time.
+type Date string
+
+// DatePrecision describes how much of a Date is actually known.
+type DatePrecision int
+
+const (
+	// DatePrecisionNone is the precision of an empty or unparseable Date
+	DatePrecisionNone DatePrecision = iota
+
+	// DatePrecisionYear means only the year is known (e.g. "1985")
+	DatePrecisionYear
+
+	// DatePrecisionMonth means the year and month are known (e.g. "1985-03")
+	DatePrecisionMonth
+
+	// DatePrecisionDay means the full date is known (e.g. "1985-03-21")
+	DatePrecisionDay
+
+	// DatePrecisionTime means a full timestamp is known (e.g. "1985-03-21T10:00:00")
+	DatePrecisionTime
+)
+
+// dateLayouts lists the formats Pipl is known to use, most precise first.
+var dateLayouts = []struct {
+	layout    string
+	precision DatePrecision
+}{
+	{time.RFC3339Nano, DatePrecisionTime},
+	{"2006-01-02T15:04:05.999999999", DatePrecisionTime},
+	{"2006-01-02 15:04:05.999999999", DatePrecisionTime},
+	{"2006-01-02", DatePrecisionDay},
+	{"2006-01", DatePrecisionMonth},
+	{"2006", DatePrecisionYear},
+}
+
+// NewDate formats t as a Date, keeping only as much of it as precision allows.
+func NewDate(t time.Time, precision DatePrecision) Date {
+	switch precision {
+	case DatePrecisionYear:
+		return Date(t.Format("2006"))
+	case DatePrecisionMonth:
+		return Date(t.Format("2006-01"))
+	case DatePrecisionDay:
+		return Date(t.Format("2006-01-02"))
+	case DatePrecisionTime:
+		return Date(t.Format("2006-01-02T15:04:05"))
+	}
+	return ""
+}
+
+// Parse converts the date into a time.Time along with its precision. Missing
+// parts (month, day, time of day) are set to the start of the known period.
+// An empty Date parses without error as the zero time with DatePrecisionNone.
+func (date Date) Parse() (time.Time, DatePrecision, error) {
+	if date == "" {
+		return time.Time{}, DatePrecisionNone, nil
+	}
+	for _, candidate := range dateLayouts {
+		parsed, err := time.Parse(candidate.layout, string(date))
+		if err == nil {
+			return parsed, candidate.precision, nil
+		}
+	}
+	return time.Time{}, DatePrecisionNone, fmt.Errorf("pipl: unrecognized date format %q", string(date))
+}
+
+// Time returns the start of the period described by the date, or the zero
+// time if the date is empty or can't be parsed.
+func (date Date) Time() time.Time {
+	parsed, _, _ := date.Parse()
+	return parsed
+}
+
+// Precision returns how much of the date is known.
+func (date Date) Precision() DatePrecision {
+	_, precision, _ := date.Parse()
+	return precision
+}
+
+// IsZero reports whether the date is empty or can't be parsed.
+func (date Date) IsZero() bool {
+	return date.Time().IsZero()
+}
+
+// Period returns the span of time covered by the date, taking its precision
+// into account: "2012" covers all of 2012, "2012-03" all of March 2012. The end
+// is exclusive. Both values are zero if the date is empty or can't be parsed.
+func (date Date) Period() (start time.Time, end time.Time) {
+	start, precision, err := date.Parse()
+	if err != nil {
+		return time.Time{}, time.Time{}
+	}
+	switch precision {
+	case DatePrecisionYear:
+		end = start.AddDate(1, 0, 0)
+	case DatePrecisionMonth:
+		end = start.AddDate(0, 1, 0)
+	case DatePrecisionDay:
+		end = start.AddDate(0, 0, 1)
+	case DatePrecisionTime:
+		end = start
+	}
+	return start, end
+}
+
+// Before reports whether the date is known and its start is before t.
+func (date Date) Before(t time.Time) bool {
+	parsed := date.Time()
+	return !parsed.IsZero() && parsed.Before(t)
+}
+
+// After reports whether the date is known and its start is after t. For
+// example, to check that a record was seen within the last six months:
+//
+//	validity.LastSeen.After(time.Now().AddDate(0, -6, 0))
+func (date Date) After(t time.Time) bool {
+	parsed := date.Time()
+	return !parsed.IsZero() && parsed.After(t)
+}
+
+// SeenSince reports whether the record was last seen after t. Records without
+// a last_seen date are not considered seen.
+func (validity Validity) SeenSince(t time.Time) bool {
+	return validity.LastSeen.After(t)
+}
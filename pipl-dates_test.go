@@ -0,0 +1,66 @@
+package pipl_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/xpcmdshell/pipl"
+)
+
+func TestDateParse(t *testing.T) {
+	cases := []struct {
+		in        pipl.Date
+		want      time.Time
+		precision pipl.DatePrecision
+	}{
+		{"", time.Time{}, pipl.DatePrecisionNone},
+		{"1985", time.Date(1985, 1, 1, 0, 0, 0, 0, time.UTC), pipl.DatePrecisionYear},
+		{"1985-03", time.Date(1985, 3, 1, 0, 0, 0, 0, time.UTC), pipl.DatePrecisionMonth},
+		{"1985-03-21", time.Date(1985, 3, 21, 0, 0, 0, 0, time.UTC), pipl.DatePrecisionDay},
+		{"2016-11-07T13:05:00", time.Date(2016, 11, 7, 13, 5, 0, 0, time.UTC), pipl.DatePrecisionTime},
+		{"2016-11-07T13:05:00Z", time.Date(2016, 11, 7, 13, 5, 0, 0, time.UTC), pipl.DatePrecisionTime},
+	}
+	for _, c := range cases {
+		got, precision, err := c.in.Parse()
+		if err != nil {
+			t.Errorf("%q: unexpected error: %v", c.in, err)
+			continue
+		}
+		if !got.Equal(c.want) || precision != c.precision {
+			t.Errorf("%q: got (%v, %v), want (%v, %v)", c.in, got, precision, c.want, c.precision)
+		}
+	}
+	if _, _, err := pipl.Date("last tuesday").Parse(); err == nil {
+		t.Error("expected an error for an unrecognized date")
+	}
+}
+
+func TestDatePeriod(t *testing.T) {
+	start, end := pipl.Date("2012-02").Period()
+	if !start.Equal(time.Date(2012, 2, 1, 0, 0, 0, 0, time.UTC)) || !end.Equal(time.Date(2012, 3, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected period: %v - %v", start, end)
+	}
+}
+
+func TestDateRoundTrip(t *testing.T) {
+	in := `{"@valid_since":"2008-07","@last_seen":"2016-11-07T13:05:00","date_range":{"start":"1985-03-21","end":"1985-03-21"}}`
+	var dob pipl.DateOfBirth
+	if err := json.Unmarshal([]byte(in), &dob); err != nil {
+		t.Fatal(err)
+	}
+	out, err := json.Marshal(dob)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != in {
+		t.Errorf("round trip changed the document:\n got %s\nwant %s", out, in)
+	}
+	cutoff := time.Date(2016, 6, 1, 0, 0, 0, 0, time.UTC)
+	if !dob.SeenSince(cutoff) {
+		t.Error("expected record to have been seen since the cutoff")
+	}
+	if dob.ValidSince.Precision() != pipl.DatePrecisionMonth {
+		t.Errorf("unexpected precision %v", dob.ValidSince.Precision())
+	}
+}
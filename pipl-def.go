@@ -3,99 +3,122 @@
 // wrapping, check out their official API reference: https://docs.pipl.com/reference/#overview
 package pipl
 
+import "encoding/json"
+
 // GUID is a unique format (but is just a string internally, since there's currently
 // nothing all that fancy done with GUIDs). Additional guid-handling code may be
 // added at a later date if needed.
 type GUID string
 
+// Every type below also has an Extra field, which holds any attributes returned
+// by the API that this package doesn't model yet (see pipl-extra.go).
+
+// Validity describes how current and reliable a piece of data is. ValidSince
+// and LastSeen are Dates, which can be parsed into a time.Time when needed.
 type Validity struct {
-	ValidSince string `json:"@valid_since,omitempty"`
-	LastSeen   string `json:"@last_seen,omitempty"`
-	Current    bool   `json:"@current,omitempty"`
-	Inferred   bool   `json:"@inferred,omitempty"`
+	ValidSince Date `json:"@valid_since,omitempty"`
+	LastSeen   Date `json:"@last_seen,omitempty"`
+	Current    bool `json:"@current,omitempty"`
+	Inferred   bool `json:"@inferred,omitempty"`
 }
 
 // Name fields collectively define a possible name for a given person.
 // If a search did not return information for a given field, it will be empty.
 type Name struct {
 	Validity
-	Type       string `json:"@type,omitempty"`
-	First      string `json:"first,omitempty"`
-	Middle     string `json:"middle,omitempty"`
-	Last       string `json:"last,omitempty"`
-	Prefix     string `json:"prefix,omitempty"`
-	Suffix     string `json:"suffix,omitempty"`
-	Raw        string `json:"raw,omitempty"`
-	Display    string `json:"display,omitempty"`
+	Type    NameType `json:"@type,omitempty"`
+	First   string   `json:"first,omitempty"`
+	Middle  string   `json:"middle,omitempty"`
+	Last    string   `json:"last,omitempty"`
+	Prefix  string   `json:"prefix,omitempty"`
+	Suffix  string   `json:"suffix,omitempty"`
+	Raw     string   `json:"raw,omitempty"`
+	Display string   `json:"display,omitempty"`
+
+	Extra map[string]json.RawMessage `json:"-"`
 }
 
 // Address fields collectively define a possible address for a given person
 // If a search did not return information for a given field, it will be empty.
 type Address struct {
 	Validity
-	Type       string `json:"@type,omitempty"`
-	Country    string `json:"country,omitempty"`
-	State      string `json:"state,omitempty"`
-	City       string `json:"city,omitempty"`
-	Street     string `json:"street,omitempty"`
-	House      string `json:"house,omitempty"`
-	Apartment  string `json:"apartment,omitempty"`
-	ZipCode    string `json:"zip_code,omitempty"`
-	POBox      string `json:"po_box,omitempty"`
-	Raw        string `json:"raw,omitempty"`
-	Display    string `json:"display,omitempty"`
+	Type      AddressType `json:"@type,omitempty"`
+	Country   string      `json:"country,omitempty"`
+	State     string      `json:"state,omitempty"`
+	City      string      `json:"city,omitempty"`
+	Street    string      `json:"street,omitempty"`
+	House     string      `json:"house,omitempty"`
+	Apartment string      `json:"apartment,omitempty"`
+	ZipCode   string      `json:"zip_code,omitempty"`
+	POBox     string      `json:"po_box,omitempty"`
+	Raw       string      `json:"raw,omitempty"`
+	Display   string      `json:"display,omitempty"`
+
+	Extra map[string]json.RawMessage `json:"-"`
 }
 
 // Phone fields collectively define a possible phone number for a given person
 // If a search did not return information for a given field, it will be empty.
 type Phone struct {
 	Validity
-	Type                 string `json:"@type,omitempty"`
-	CountryCode          int    `json:"country_code,omitempty"`
-	Number               int    `json:"number,omitempty"`
-	Extension            int    `json:"extension,omitempty"`
-	Raw                  string `json:"raw,omitempty"`
-	Display              string `json:"display,omitempty"`
-	DisplayInternational string `json:"display_international,omitempty"`
+	Type                 PhoneType `json:"@type,omitempty"`
+	CountryCode          int       `json:"country_code,omitempty"`
+	Number               int       `json:"number,omitempty"`
+	Extension            int       `json:"extension,omitempty"`
+	Raw                  string    `json:"raw,omitempty"`
+	Display              string    `json:"display,omitempty"`
+	DisplayInternational string    `json:"display_international,omitempty"`
+
+	Extra map[string]json.RawMessage `json:"-"`
 }
 
 // Email fields collectively define a possible email address for a given person
 // If a search did not return information for a given field, it will be empty.
 type Email struct {
 	Validity
-	Type          string `json:"@type,omitempty"`
-	Address       string `json:"address,omitempty"`
-	AddressMD5    string `json:"address_md5,omitempty"`
-	Disposable    bool   `json:"@disposable,omitempty"`
-	EmailProvider bool   `json:"@email_provider,omitempty"`
+	Type          EmailType `json:"@type,omitempty"`
+	Address       string    `json:"address,omitempty"`
+	AddressMD5    string    `json:"address_md5,omitempty"`
+	Disposable    bool      `json:"@disposable,omitempty"`
+	EmailProvider bool      `json:"@email_provider,omitempty"`
+
+	Extra map[string]json.RawMessage `json:"-"`
 }
 
 // Username fields collectively define a possible username used by a given person.
 // If a search did not return information for a given field, it will be empty.
 type Username struct {
 	Validity
-	Content    string `json:"content,omitempty"`
+	Content string `json:"content,omitempty"`
+
+	Extra map[string]json.RawMessage `json:"-"`
 }
 
 // UserID fields collectively define a possible UserID used by a given person.
 // If a search did not return information for a given field, it will be empty.
 type UserID struct {
 	Validity
-	Content    string `json:"content,omitempty"`
+	Content string `json:"content,omitempty"`
+
+	Extra map[string]json.RawMessage `json:"-"`
 }
 
 // DateRange specifies a range of time by a start and end date
 type DateRange struct {
 	Validity
-	Start      string `json:"start,omitempty"`
-	End        string `json:"end,omitempty"`
+	Start Date `json:"start,omitempty"`
+	End   Date `json:"end,omitempty"`
+
+	Extra map[string]json.RawMessage `json:"-"`
 }
 
 // DateOfBirth specififes a possible DOB for a person.
 type DateOfBirth struct {
 	Validity
-	DateRange  DateRange `json:"date_range,omitempty"`
-	Display    string    `json:"display,omitempty"`
+	DateRange *DateRange `json:"date_range,omitempty"`
+	Display   string     `json:"display,omitempty"`
+
+	Extra map[string]json.RawMessage `json:"-"`
 }
 
 // Image specifies a link to an image closely associated with the given person.
@@ -103,95 +126,126 @@ type Image struct {
 	Validity
 	URL            string `json:"url,omitempty"`
 	ThumbnailToken string `json:"thumbnail_token,omitempty"`
+
+	Extra map[string]json.RawMessage `json:"-"`
 }
 
 // Job specifies information about a possible occupation held by the given person.
 type Job struct {
 	Validity
-	Title        string    `json:"title,omitempty"`
-	Organization string    `json:"organization,omitempty"`
-	Industry     string    `json:"industry,omitempty"`
-	DateRange    DateRange `json:"date_range,omitempty"`
-	Display      string    `json:"display,omitempty"`
+	Title        string     `json:"title,omitempty"`
+	Organization string     `json:"organization,omitempty"`
+	Industry     string     `json:"industry,omitempty"`
+	DateRange    *DateRange `json:"date_range,omitempty"`
+	Display      string     `json:"display,omitempty"`
+
+	Extra map[string]json.RawMessage `json:"-"`
 }
 
 // Education specifies a possible
 type Education struct {
 	Validity
-	Degree     string    `json:"degree,omitempty"`
-	School     string    `json:"school,omitempty"`
-	DateRange  DateRange `json:"date_range,omitempty"`
-	Display    string    `json:"display,omitempty"`
+	Degree    string     `json:"degree,omitempty"`
+	School    string     `json:"school,omitempty"`
+	DateRange *DateRange `json:"date_range,omitempty"`
+	Display   string     `json:"display,omitempty"`
+
+	Extra map[string]json.RawMessage `json:"-"`
 }
 
 // Gender contains a  possible gender of the given person.
-// Gender is one of: "male", "female" (There is no default value for this field)
+// Content is one of GenderMale or GenderFemale (there is no default value for this field).
 type Gender struct {
 	Validity
-	Content    string `json:"content,omitempty"`
+	Content GenderValue `json:"content,omitempty"`
+
+	Extra map[string]json.RawMessage `json:"-"`
 }
 
 // Ethnicity contains a possible ethnicity of given person.
 type Ethnicity struct {
 	Validity
-	Content    string `json:"content,omitempty"`
+	Content string `json:"content,omitempty"`
+
+	Extra map[string]json.RawMessage `json:"-"`
 }
 
 // Language contains information about a possible language known by the given person.
 type Language struct {
 	Validity
-	Language   string `json:"language,omitempty"`
-	Region     string `json:"region,omitempty"`
-	Display    string `json:"display,omitempty"`
+	Language string `json:"language,omitempty"`
+	Region   string `json:"region,omitempty"`
+	Display  string `json:"display,omitempty"`
+
+	Extra map[string]json.RawMessage `json:"-"`
 }
 
 // OriginCountry contains information about a possible origin country of the
 // given person.
 type OriginCountry struct {
 	Validity
-	Country    string `json:"country,omitempty"`
+	Country string `json:"country,omitempty"`
+
+	Extra map[string]json.RawMessage `json:"-"`
 }
 
-// Relationship contains information about a person who is closely related to
-// the person being searched. This can be family members, spouses, children, etc.
-// Type  and Subtype contain information about the nature of the relationship to
-// the person being searched. For example, Type = "Family", Subtype = "Father".
-// Type can be one of: "work", "family", "friend" (default), "other"
-type Relationship struct {
-	Validity
-	Type            string          `json:"@type,omitempty"`
-	Subtype         string          `json:"@subtype,omitempty"`
+// PersonFields holds the data fields that Pipl reports for a person. It's shared
+// by Person, Relationship and Source, which all carry the same set of attributes
+// and add their own metadata on top. Fields are promoted, so they can be used
+// directly (e.g. person.Names, relationship.Emails, source.Phones).
+type PersonFields struct {
 	Names           []Name          `json:"names,omitempty"`
 	Emails          []Email         `json:"emails,omitempty"`
 	Usernames       []Username      `json:"usernames,omitempty"`
 	Phones          []Phone         `json:"phones,omitempty"`
-	Gender          Gender          `json:"gender,omitempty"`
-	DateOfBirth     DateOfBirth     `json:"dob,omitempty"`
+	Gender          *Gender         `json:"gender,omitempty"`
+	DateOfBirth     *DateOfBirth    `json:"dob,omitempty"`
 	Languages       []Language      `json:"languages,omitempty"`
 	Ethnicities     []Ethnicity     `json:"ethnicities,omitempty"`
 	OriginCountries []OriginCountry `json:"origin_countries,omitempty"`
 	Addresses       []Address       `json:"addresses,omitempty"`
 	Jobs            []Job           `json:"jobs,omitempty"`
 	Educations      []Education     `json:"educations,omitempty"`
-	Relationships   []Relationship  `json:"relationships,omitempty"`
-	UserIDs         []UserID        `json:"user_ids,omitempty"`
 	Images          []Image         `json:"images,omitempty"`
+	UserIDs         []UserID        `json:"user_ids,omitempty"`
+	URLs            []URL           `json:"urls,omitempty"`
+	Relationships   []Relationship  `json:"relationships,omitempty"`
+	Tags            []Tag           `json:"tags,omitempty"`
+}
+
+// Relationship contains information about a person who is closely related to
+// the person being searched. This can be family members, spouses, children, etc.
+// Type  and Subtype contain information about the nature of the relationship to
+// the person being searched. For example, Type = "family", Subtype = "Father".
+// Type is one of the RelationshipType constants (RelationshipTypeFriend is the default).
+type Relationship struct {
+	Validity
+	Type    RelationshipType    `json:"@type,omitempty"`
+	Subtype RelationshipSubtype `json:"@subtype,omitempty"`
+	PersonFields
+
+	Extra map[string]json.RawMessage `json:"-"`
 }
 
 // URL contains information about a URL that is closely associated with a given person.
 type URL struct {
 	Validity
-	SourceID   string `json:"@source_id,omitempty"`
-	Domain     string `json:"@domain,omitempty"`
-	Name       string `json:"@name,omitempty"`
-	Category   string `json:"@category,omitempty"`
-	URL        string `json:"url,omitempty"`
+	SourceID  string `json:"@source_id,omitempty"`
+	Domain    string `json:"@domain,omitempty"`
+	Name      string `json:"@name,omitempty"`
+	Category  string `json:"@category,omitempty"`
+	Sponsored bool   `json:"@sponsored,omitempty"`
+	URL       string `json:"url,omitempty"`
+
+	Extra map[string]json.RawMessage `json:"-"`
 }
 
 // Tag contains content classification information
 type Tag struct {
 	Classification string `json:"@classification,omitempty"`
 	Content        string `json:"content,omitempty"`
+
+	Extra map[string]json.RawMessage `json:"-"`
 }
 
 // Person contains all the information pertaining to a possible person match,
@@ -200,56 +254,31 @@ type Tag struct {
 // float: 0 <= Match <= 1. More potential matches returned in a search decreases
 // the overall confidence of all matches.
 type Person struct {
-	ID              GUID            `json:"@id,omitempty"`
-	Inferred        bool            `json:"@inferred,omitempty"`
-	SearchPointer   string          `json:"@search_pointer,omitempty"`
-	Match           float32         `json:"@match,omitempty"`
-	Names           []Name          `json:"names,omitempty"`
-	Emails          []Email         `json:"emails,omitempty"`
-	Usernames       []Username      `json:"usernames,omitempty"`
-	Phones          []Phone         `json:"phones,omitempty"`
-	Gender          *Gender         `json:"gender,omitempty"`
-	DateOfBirth     *DateOfBirth    `json:"dob,omitempty"`
-	Languages       []Language      `json:"languages,omitempty"`
-	Ethnicities     []Ethnicity     `json:"ethnicities,omitempty"`
-	OriginCountries []OriginCountry `json:"origin_countries,omitempty"`
-	Addresses       []Address       `json:"addresses,omitempty"`
-	Jobs            []Job           `json:"jobs,omitempty"`
-	Educations      []Education     `json:"educations,omitempty"`
-	Relationships   []Relationship  `json:"relationships,omitempty"`
-	UserIDs         []UserID        `json:"user_ids,omitempty"`
-	URLs            []URL           `json:"urls,omitempty"`
+	ID            GUID    `json:"@id,omitempty"`
+	Inferred      bool    `json:"@inferred,omitempty"`
+	SearchPointer string  `json:"@search_pointer,omitempty"`
+	Match         float32 `json:"@match,omitempty"`
+	PersonFields
+
+	Extra map[string]json.RawMessage `json:"-"`
 }
 
 // Source contains all the information for a given person, gathered from a
 // single source. The source structure contains information about the name,
 // domain, category, and source URL (amongst other fields).
 type Source struct {
-	ID              string          `json:"@id"`
-	Name            string          `json:"@name"`
-	Category        string          `json:"@category"`
-	Domain          string          `json:"@domain"`
-	PersonID        GUID            `json:"@person_id"`
-	Sponsored       bool            `json:"@sponsored"`
-	OriginURL       string          `json:"@origin_url"`
-	Match           float32         `json:"@match"`
-	Premium         bool            `json:"@premium"`
-	Names           []Name          `json:"names"`
-	Emails          []Email         `json:"emails"`
-	Usernames       []Username      `json:"usernames"`
-	Phones          []Phone         `json:"phones"`
-	Gender          Gender          `json:"gender"`
-	DateOfBirth     DateOfBirth     `json:"dob"`
-	Languages       []Language      `json:"languages"`
-	Ethnicities     []Ethnicity     `json:"ethnicities"`
-	OriginCountries []OriginCountry `json:"origin_countries"`
-	Addresses       []Address       `json:"addresses"`
-	Jobs            []Job           `json:"jobs"`
-	Educations      []Education     `json:"educations"`
-	Relationships   []Relationship  `json:"relationships"`
-	UserIDs         []UserID        `json:"user_ids"`
-	URLs            []URL           `json:"urls"`
-	Tags            []Tag           `json:"tags"`
+	ID        string  `json:"@id,omitempty"`
+	Name      string  `json:"@name,omitempty"`
+	Category  string  `json:"@category,omitempty"`
+	Domain    string  `json:"@domain,omitempty"`
+	PersonID  GUID    `json:"@person_id,omitempty"`
+	Sponsored bool    `json:"@sponsored,omitempty"`
+	OriginURL string  `json:"@origin_url,omitempty"`
+	Match     float32 `json:"@match,omitempty"`
+	Premium   bool    `json:"@premium,omitempty"`
+	PersonFields
+
+	Extra map[string]json.RawMessage `json:"-"`
 }
 
 // FieldCount contains the count of various attributes returned from a search
@@ -272,6 +301,8 @@ type FieldCount struct {
 	Images          int `json:"images"`
 	Genders         int `json:"genders"`
 	OriginCountries int `json:"origin_countries"`
+
+	Extra map[string]json.RawMessage `json:"-"`
 }
 
 // AvailableData aggregates the counts for found attributes that are relevant to
@@ -279,6 +310,8 @@ type FieldCount struct {
 type AvailableData struct {
 	Basic   FieldCount `json:"basic"`
 	Premium FieldCount `json:"premium"`
+
+	Extra map[string]json.RawMessage `json:"-"`
 }
 
 // Response holds search results and general request information returned from
@@ -286,17 +319,20 @@ type AvailableData struct {
 // A search may be successful, but have some warnings. These are held in the
 // Warnings field.
 type Response struct {
-	HTTPStatusCode    int           `json:"@http_status_code"`
-	VisibleSources    int           `json:"@visible_sources"`
-	AvailableSources  int           `json:"@available_sources"`
-	PersonsCount      int           `json:"@persons_count"`
-	SearchID          string        `json:"@search_id"`
-	Query             Person        `json:"query"`
-	MatchRequirements string        `json:"match_requirements"`
-	AvailableData     AvailableData `json:"available_data"`
-	Error             string        `json:"error"`
-	Warnings          []string      `json:"warnings"`
-	Person            Person        `json:"person"`
-	PossiblePersons   []Person      `json:"possible_persons"`
-	Sources           []Source      `json:"sources"`
+	HTTPStatusCode             int                        `json:"@http_status_code,omitempty"`
+	VisibleSources             int                        `json:"@visible_sources,omitempty"`
+	AvailableSources           int                        `json:"@available_sources,omitempty"`
+	PersonsCount               int                        `json:"@persons_count"`
+	SearchID                   string                     `json:"@search_id,omitempty"`
+	Query                      Person                     `json:"query"`
+	MatchRequirements          MatchRequirements          `json:"match_requirements,omitempty"`
+	SourceCategoryRequirements SourceCategoryRequirements `json:"source_category_requirements,omitempty"`
+	AvailableData              AvailableData              `json:"available_data"`
+	Error                      string                     `json:"error,omitempty"`
+	Warnings                   []string                   `json:"warnings,omitempty"`
+	Person                     Person                     `json:"person"`
+	PossiblePersons            []Person                   `json:"possible_persons,omitempty"`
+	Sources                    Sources                    `json:"sources,omitempty"`
+
+	Extra map[string]json.RawMessage `json:"-"`
 }
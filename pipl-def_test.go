@@ -0,0 +1,103 @@
+package pipl_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/xpcmdshell/pipl"
+)
+
+// loadResponse decodes one of the sample API responses in testdata.
+func loadResponse(t *testing.T, name string) (*pipl.Response, []byte) {
+	t.Helper()
+	data, err := ioutil.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	response := new(pipl.Response)
+	if err := json.Unmarshal(data, response); err != nil {
+		t.Fatalf("%s: %v", name, err)
+	}
+	return response, data
+}
+
+// subtree pulls a top-level member out of a JSON document as a generic value.
+func subtree(t *testing.T, data []byte, key string) interface{} {
+	t.Helper()
+	var document map[string]interface{}
+	if err := json.Unmarshal(data, &document); err != nil {
+		t.Fatal(err)
+	}
+	return document[key]
+}
+
+func TestResponseRoundTrip(t *testing.T) {
+	for _, name := range []string{"person_response.json", "possible_persons_response.json"} {
+		response, original := loadResponse(t, name)
+		encoded, err := json.Marshal(response)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		decoded := new(pipl.Response)
+		if err := json.Unmarshal(encoded, decoded); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if !reflect.DeepEqual(response, decoded) {
+			t.Errorf("%s: response changed after a round trip", name)
+		}
+		for _, key := range []string{"person", "possible_persons", "sources", "query"} {
+			if !reflect.DeepEqual(subtree(t, original, key), subtree(t, encoded, key)) {
+				t.Errorf("%s: %q changed after a round trip", name, key)
+			}
+		}
+	}
+}
+
+func TestSharedPersonFields(t *testing.T) {
+	response, _ := loadResponse(t, "person_response.json")
+	person := response.Person
+	if len(person.Images) != 1 || person.Gender == nil || person.Gender.Content != pipl.GenderMale {
+		t.Errorf("person fields not decoded: %+v", person.PersonFields)
+	}
+	friend := person.Relationships[1]
+	if friend.Gender == nil || friend.Gender.Content != pipl.GenderFemale || len(friend.Emails) != 1 {
+		t.Errorf("relationship fields not decoded: %+v", friend)
+	}
+	if person.Relationships[0].Gender != nil {
+		t.Error("missing relationship gender should decode as nil")
+	}
+	facebook := response.Sources[1]
+	if !facebook.Sponsored || len(facebook.Images) != 1 || len(facebook.Tags) != 1 {
+		t.Errorf("source fields not decoded: %+v", facebook)
+	}
+	if !person.URLs[1].Sponsored {
+		t.Error("expected sponsored URL")
+	}
+}
+
+func TestUnknownFieldsArePreserved(t *testing.T) {
+	in := `{"@id":"a7a2a3a1","@future_flag":true,"names":[{"first":"Clark","last":"Kent","pronunciation":"klark"}],"shoe_size":{"us":11}}`
+	var person pipl.Person
+	if err := json.Unmarshal([]byte(in), &person); err != nil {
+		t.Fatal(err)
+	}
+	if string(person.Extra["shoe_size"]) != `{"us":11}` || string(person.Names[0].Extra["pronunciation"]) != `"klark"` {
+		t.Errorf("unknown fields not captured: %v %v", person.Extra, person.Names[0].Extra)
+	}
+	if _, ok := person.Extra["names"]; ok {
+		t.Error("known fields should not end up in Extra")
+	}
+	out, err := json.Marshal(person)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var want, got interface{}
+	json.Unmarshal([]byte(in), &want)
+	json.Unmarshal(out, &got)
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("unknown fields lost on re-encoding:\n got %s\nwant %s", out, in)
+	}
+}
@@ -0,0 +1,193 @@
+package pipl
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// DiffKind describes how a value differs between two profiles.
+type DiffKind string
+
+const (
+	// DiffAdded means the value only appears in the new profile
+	DiffAdded DiffKind = "added"
+
+	// DiffRemoved means the value only appears in the old profile
+	DiffRemoved DiffKind = "removed"
+
+	// DiffChanged means the value appears in both profiles, but some of its
+	// attributes (validity, date range, display form...) differ
+	DiffChanged DiffKind = "changed"
+)
+
+// DiffEntry describes a single difference between two profiles. Old and New
+// hold the values themselves (e.g. *Address), and are nil for added and
+// removed values respectively. Changes lists the attributes that differ for
+// changed values, e.g. `@current: true -> (none)`.
+type DiffEntry struct {
+	Category FieldCategory `json:"category"`
+	Kind     DiffKind      `json:"kind"`
+	Label    string        `json:"label"`
+	Old      interface{}   `json:"old,omitempty"`
+	New      interface{}   `json:"new,omitempty"`
+	Changes  []string      `json:"changes,omitempty"`
+}
+
+// PersonDiff holds the differences between two profiles, grouped by field
+// category in the order of FieldCategories.
+type PersonDiff struct {
+	Entries []DiffEntry `json:"entries"`
+}
+
+// DiffPersons compares two profiles of the same individual, e.g. the results of
+// searches run some time apart. Values are matched by their normalised form, not
+// by struct equality, so a phone that was reformatted isn't reported as removed
+// and added again. Single-valued fields (gender, dob) that differ are reported
+// as changed.
+func DiffPersons(oldPerson *Person, newPerson *Person) *PersonDiff {
+	diff := new(PersonDiff)
+	for _, category := range FieldCategories {
+		oldItems, newItems := oldPerson.PersonFields.items(category), newPerson.PersonFields.items(category)
+		if personFieldIndex[category].single {
+			diff.diffSingle(category, oldItems, newItems)
+			continue
+		}
+		matched := make([]bool, len(newItems))
+		for _, oldItem := range oldItems {
+			found := -1
+			for j, newItem := range newItems {
+				if !matched[j] && newItem.key() == oldItem.key() {
+					found = j
+					break
+				}
+			}
+			if found < 0 {
+				diff.Entries = append(diff.Entries, DiffEntry{Category: category, Kind: DiffRemoved, Label: oldItem.label(), Old: oldItem})
+				continue
+			}
+			matched[found] = true
+			diff.diffItem(category, oldItem, newItems[found])
+		}
+		for j, newItem := range newItems {
+			if !matched[j] {
+				diff.Entries = append(diff.Entries, DiffEntry{Category: category, Kind: DiffAdded, Label: newItem.label(), New: newItem})
+			}
+		}
+	}
+	return diff
+}
+
+func (diff *PersonDiff) diffSingle(category FieldCategory, oldItems []fieldItem, newItems []fieldItem) {
+	switch {
+	case len(oldItems) == 0 && len(newItems) == 0:
+	case len(oldItems) == 0:
+		diff.Entries = append(diff.Entries, DiffEntry{Category: category, Kind: DiffAdded, Label: newItems[0].label(), New: newItems[0]})
+	case len(newItems) == 0:
+		diff.Entries = append(diff.Entries, DiffEntry{Category: category, Kind: DiffRemoved, Label: oldItems[0].label(), Old: oldItems[0]})
+	default:
+		diff.diffItem(category, oldItems[0], newItems[0])
+	}
+}
+
+// diffItem records a DiffChanged entry if the two values differ in any way.
+func (diff *PersonDiff) diffItem(category FieldCategory, oldItem fieldItem, newItem fieldItem) {
+	changes := attributeChanges(oldItem, newItem)
+	if len(changes) > 0 {
+		diff.Entries = append(diff.Entries, DiffEntry{Category: category, Kind: DiffChanged, Label: newItem.label(), Old: oldItem, New: newItem, Changes: changes})
+	}
+}
+
+// attributeChanges compares the JSON attributes of two values, so the names in
+// the result match the API documentation (e.g. "date_range.end").
+func attributeChanges(oldItem fieldItem, newItem fieldItem) []string {
+	oldAttributes, newAttributes := flattenJSON(oldItem), flattenJSON(newItem)
+	names := make([]string, 0, len(oldAttributes)+len(newAttributes))
+	for name := range oldAttributes {
+		names = append(names, name)
+	}
+	for name := range newAttributes {
+		if _, ok := oldAttributes[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	var changes []string
+	for _, name := range names {
+		oldValue, newValue := oldAttributes[name], newAttributes[name]
+		if oldValue != newValue {
+			changes = append(changes, fmt.Sprintf("%s: %s -> %s", name, firstNonEmpty(oldValue, "(none)"), firstNonEmpty(newValue, "(none)")))
+		}
+	}
+	return changes
+}
+
+// flattenJSON encodes value and flattens the result into "a.b[0].c" style
+// paths mapped to their encoded scalar values.
+func flattenJSON(value interface{}) map[string]string {
+	flattened := make(map[string]string)
+	data, err := json.Marshal(value)
+	if err != nil {
+		return flattened
+	}
+	var decoded interface{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return flattened
+	}
+	flattenValue(decoded, "", flattened)
+	return flattened
+}
+
+func flattenValue(value interface{}, path string, flattened map[string]string) {
+	switch typed := value.(type) {
+	case map[string]interface{}:
+		for key, member := range typed {
+			flattenValue(member, joinPath(path, key), flattened)
+		}
+	case []interface{}:
+		for i, member := range typed {
+			flattenValue(member, fmt.Sprintf("%s[%d]", path, i), flattened)
+		}
+	default:
+		encoded, _ := json.Marshal(typed)
+		flattened[path] = string(encoded)
+	}
+}
+
+// IsEmpty reports whether the two profiles hold the same data.
+func (diff *PersonDiff) IsEmpty() bool {
+	return len(diff.Entries) == 0
+}
+
+// Category returns the entries for a single field category.
+func (diff *PersonDiff) Category(category FieldCategory) []DiffEntry {
+	var entries []DiffEntry
+	for _, entry := range diff.Entries {
+		if entry.Category == category {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+// String renders the diff one entry per line, prefixed with "+" for added,
+// "-" for removed and "~" for changed values, e.g.:
+//
+//	~ phones: +1 978-555-0145 (@current: true -> (none))
+//	+ addresses: 10 Hickory Lane, Smallville, Kansas
+func (diff *PersonDiff) String() string {
+	if diff.IsEmpty() {
+		return "no changes"
+	}
+	prefixes := map[DiffKind]string{DiffAdded: "+", DiffRemoved: "-", DiffChanged: "~"}
+	var builder strings.Builder
+	for _, entry := range diff.Entries {
+		fmt.Fprintf(&builder, "%s %s: %s", prefixes[entry.Kind], entry.Category, entry.Label)
+		if len(entry.Changes) > 0 {
+			fmt.Fprintf(&builder, " (%s)", strings.Join(entry.Changes, "; "))
+		}
+		builder.WriteByte('\n')
+	}
+	return builder.String()
+}
@@ -0,0 +1,56 @@
+package pipl_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/xpcmdshell/pipl"
+)
+
+func TestDiffPersons(t *testing.T) {
+	old := pipl.NewPerson()
+	old.AddName("Clark", "", "Kent", "", "")
+	old.Phones = []pipl.Phone{{CountryCode: 1, Number: 9785550145, Validity: pipl.Validity{Current: true}}}
+	old.AddJob("Field Reporter", "Daily Planet", "", "2008-04-01", "")
+	old.AddEmail("clark@dailyplanet.example.com")
+	old.SetGender(pipl.GenderMale)
+
+	updated := pipl.NewPerson()
+	updated.AddName("CLARK", "", "KENT", "", "")
+	updated.Phones = []pipl.Phone{{CountryCode: 1, Number: 9785550145}}
+	updated.AddJob("Field Reporter", "Daily Planet", "", "2008-04-01", "2019-01-01")
+	updated.AddAddressRaw("10 Hickory Lane, Smallville, Kansas")
+	updated.SetGender(pipl.GenderMale)
+
+	diff := pipl.DiffPersons(old, updated)
+	if len(diff.Entries) != 5 {
+		t.Fatalf("unexpected diff:\n%s", diff)
+	}
+	if name := diff.Category(pipl.CategoryNames); len(name) != 1 || name[0].Kind != pipl.DiffChanged {
+		t.Errorf("names should be matched regardless of case: %+v", name)
+	}
+	if added := diff.Category(pipl.CategoryAddresses); len(added) != 1 || added[0].Kind != pipl.DiffAdded {
+		t.Errorf("expected an added address: %+v", added)
+	}
+	if removed := diff.Category(pipl.CategoryEmails); len(removed) != 1 || removed[0].Kind != pipl.DiffRemoved {
+		t.Errorf("expected a removed email: %+v", removed)
+	}
+	phone := diff.Category(pipl.CategoryPhones)
+	if len(phone) != 1 || phone[0].Kind != pipl.DiffChanged || phone[0].Changes[0] != "@current: true -> (none)" {
+		t.Errorf("expected the phone to no longer be current: %+v", phone)
+	}
+	job := diff.Category(pipl.CategoryJobs)
+	if len(job) != 1 || job[0].Changes[0] != `date_range.end: (none) -> "2019-01-01"` {
+		t.Errorf("expected the job to have ended: %+v", job)
+	}
+	if !strings.Contains(diff.String(), "+ addresses: 10 Hickory Lane, Smallville, Kansas\n") {
+		t.Errorf("unexpected rendering:\n%s", diff)
+	}
+	if _, err := json.Marshal(diff); err != nil {
+		t.Fatal(err)
+	}
+	if !pipl.DiffPersons(old, old).IsEmpty() {
+		t.Error("a profile should not differ from itself")
+	}
+}
@@ -0,0 +1,120 @@
+package pipl
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// SchemaDriftKind describes how a response differs from the schema modelled by
+// this package.
+type SchemaDriftKind string
+
+const (
+	// DriftUnknownField means the response contained an attribute that isn't
+	// modelled (it's kept in the Extra field of the enclosing value)
+	DriftUnknownField SchemaDriftKind = "unknown_field"
+
+	// DriftUnknownValue means a typed field (gender, address type, etc.) held
+	// a value that isn't one of the documented constants
+	DriftUnknownValue SchemaDriftKind = "unknown_value"
+)
+
+// SchemaDrift describes a single difference between a response and the
+// modelled schema. Path is a JSON-style path such as "person.emails[0].@type".
+type SchemaDrift struct {
+	Path  string
+	Kind  SchemaDriftKind
+	Value string
+}
+
+// ErrSchemaDrift is an error type listing everything in a response that the
+// package doesn't model. It's reported to Client.OnSchemaDrift in strict mode,
+// and returned by searches when Client.FailOnSchemaDrift is also set.
+type ErrSchemaDrift struct {
+	Drift []SchemaDrift
+}
+
+func (err *ErrSchemaDrift) Error() string {
+	paths := make([]string, 0, len(err.Drift))
+	for _, drift := range err.Drift {
+		paths = append(paths, fmt.Sprintf("%s (%s)", drift.Path, drift.Kind))
+	}
+	return fmt.Sprintf("The response does not match the modelled schema: %s", strings.Join(paths, ", "))
+}
+
+// knownValuer is implemented by the typed enumerations in pipl-enums.go.
+type knownValuer interface {
+	IsKnown() bool
+}
+
+// DetectSchemaDrift checks a decoded response for unknown attributes and
+// unknown enumeration values. It returns nil if the response matches the
+// modelled schema. This is what the client runs in strict mode, and it can also
+// be used directly against recorded responses.
+func DetectSchemaDrift(response *Response) *ErrSchemaDrift {
+	var drift []SchemaDrift
+	collectDrift(reflect.ValueOf(response).Elem(), "", &drift)
+	if len(drift) == 0 {
+		return nil
+	}
+	sort.SliceStable(drift, func(i, j int) bool { return drift[i].Path < drift[j].Path })
+	return &ErrSchemaDrift{Drift: drift}
+}
+
+// collectDrift walks value, appending drift found at or below path.
+func collectDrift(value reflect.Value, path string, drift *[]SchemaDrift) {
+	switch value.Kind() {
+	case reflect.String:
+		if enum, ok := value.Interface().(knownValuer); ok && value.Len() > 0 && !enum.IsKnown() {
+			*drift = append(*drift, SchemaDrift{Path: path, Kind: DriftUnknownValue, Value: value.String()})
+		}
+	case reflect.Ptr:
+		if !value.IsNil() {
+			collectDrift(value.Elem(), path, drift)
+		}
+	case reflect.Slice:
+		for i := 0; i < value.Len(); i++ {
+			collectDrift(value.Index(i), fmt.Sprintf("%s[%d]", path, i), drift)
+		}
+	case reflect.Struct:
+		valueType := value.Type()
+		for i := 0; i < valueType.NumField(); i++ {
+			field := valueType.Field(i)
+			if field.Name == "Extra" && field.Type.Kind() == reflect.Map {
+				for _, key := range sortedKeys(value.Field(i)) {
+					*drift = append(*drift, SchemaDrift{Path: joinPath(path, key), Kind: DriftUnknownField, Value: string(value.Field(i).MapIndex(reflect.ValueOf(key)).Bytes())})
+				}
+				continue
+			}
+			if field.PkgPath != "" {
+				continue
+			}
+			name := strings.Split(field.Tag.Get("json"), ",")[0]
+			if field.Anonymous && name == "" {
+				collectDrift(value.Field(i), path, drift)
+				continue
+			}
+			collectDrift(value.Field(i), joinPath(path, name), drift)
+		}
+	}
+}
+
+func joinPath(path string, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// sortedKeys returns the string keys of a map value in order, so drift is
+// reported deterministically.
+func sortedKeys(mapValue reflect.Value) []string {
+	keys := make([]string, 0, mapValue.Len())
+	for _, key := range mapValue.MapKeys() {
+		keys = append(keys, key.String())
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,57 @@
+package pipl_test
+
+import (
+	"testing"
+
+	"github.com/xpcmdshell/pipl"
+)
+
+func TestRecordedResponsesHaveNoDrift(t *testing.T) {
+	for _, name := range []string{"person_response.json", "possible_persons_response.json"} {
+		response, _ := loadResponse(t, name)
+		if drift := pipl.DetectSchemaDrift(response); drift != nil {
+			t.Errorf("%s: %v", name, drift)
+		}
+	}
+}
+
+func TestStrictModeReportsDrift(t *testing.T) {
+	body := []byte(`{"@persons_count":1,"@risk_score":0.2,"person":{"emails":[{"@type":"school","address":"clark@example.edu"}],"phones":[{"number":5555550100,"@carrier":"ACME"}]}}`)
+
+	client := pipl.NewClient("test")
+	client.StrictMode = true
+	var reported *pipl.ErrSchemaDrift
+	client.OnSchemaDrift = func(drift *pipl.ErrSchemaDrift) { reported = drift }
+
+	response, err := client.ParseResponse(body)
+	if err != nil || response == nil {
+		t.Fatalf("strict mode should not fail by default: %v", err)
+	}
+	if reported == nil {
+		t.Fatal("expected drift to be reported")
+	}
+	want := []pipl.SchemaDrift{
+		{Path: "@risk_score", Kind: pipl.DriftUnknownField, Value: "0.2"},
+		{Path: "person.emails[0].@type", Kind: pipl.DriftUnknownValue, Value: "school"},
+		{Path: "person.phones[0].@carrier", Kind: pipl.DriftUnknownField, Value: `"ACME"`},
+	}
+	if len(reported.Drift) != len(want) {
+		t.Fatalf("got %+v, want %+v", reported.Drift, want)
+	}
+	for i := range want {
+		if reported.Drift[i] != want[i] {
+			t.Errorf("drift %d: got %+v, want %+v", i, reported.Drift[i], want[i])
+		}
+	}
+
+	client.FailOnSchemaDrift = true
+	response, err = client.ParseResponse(body)
+	if _, ok := err.(*pipl.ErrSchemaDrift); !ok || response == nil {
+		t.Errorf("expected the response along with an ErrSchemaDrift, got %v", err)
+	}
+
+	client.StrictMode = false
+	if _, err := client.ParseResponse(body); err != nil {
+		t.Errorf("drift should be ignored outside of strict mode: %v", err)
+	}
+}
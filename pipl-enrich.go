@@ -0,0 +1,430 @@
+package pipl
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// EnrichmentStatus is the outcome of enriching a single record.
+type EnrichmentStatus string
+
+const (
+	// EnrichmentOK means the record was matched to a full profile
+	EnrichmentOK EnrichmentStatus = "ok"
+
+	// EnrichmentNoMatch means the search didn't match anyone
+	EnrichmentNoMatch EnrichmentStatus = "no_match"
+
+	// EnrichmentAmbiguous means the search matched several possible persons,
+	// none of which was resolved to a full profile
+	EnrichmentAmbiguous EnrichmentStatus = "ambiguous"
+
+	// EnrichmentInvalid means the record doesn't hold enough valid search
+	// terms, so no search was run
+	EnrichmentInvalid EnrichmentStatus = "invalid"
+
+	// EnrichmentError means the search failed
+	EnrichmentError EnrichmentStatus = "error"
+)
+
+// Status columns appended to every enriched record.
+const (
+	EnrichmentStatusColumn = "pipl_status"
+	EnrichmentErrorColumn  = "pipl_error"
+)
+
+// EnrichmentTerms lists the search terms an input column can be mapped to.
+// Address parts (house, street...) are combined into a single address.
+var EnrichmentTerms = []string{
+	"name", "first_name", "middle_name", "last_name", "email", "phone", "username",
+	"user_id", "url", "address", "house", "street", "apartment", "city", "state",
+	"zip_code", "country", "dob", "gender", "job_title", "organization",
+}
+
+// EnrichmentOutputs lists the Pipl fields that can be appended to a record.
+// Fields holding several values (addresses, phones...) take the first current
+// one, or the first one if none is current.
+var EnrichmentOutputs = []string{
+	"name", "email", "phone", "username", "user_id", "url", "address", "job",
+	"job_title", "organization", "education", "image", "language", "gender", "dob",
+	"match", "person_id", "search_id", "persons_count",
+}
+
+// defaultEnrichmentOutputs are used when a mapping doesn't list any outputs.
+var defaultEnrichmentOutputs = []string{"address", "job_title", "match", "search_id"}
+
+// EnrichmentMapping declares how input records are turned into searches and
+// which Pipl fields are appended to them. It is usually loaded from a file,
+// e.g.:
+//
+//	{"columns": {"full_name": "name", "email": "email", "city": "city"},
+//	 "outputs": ["address", "job_title", "match", "search_id"]}
+type EnrichmentMapping struct {
+	// Columns maps input columns to search terms (see EnrichmentTerms)
+	Columns map[string]string `json:"columns"`
+
+	// Outputs lists the Pipl fields to append (see EnrichmentOutputs). The
+	// default is address, job_title, match and search_id.
+	Outputs []string `json:"outputs,omitempty"`
+
+	// Prefix is prepended to the output columns (default "pipl_")
+	Prefix string `json:"prefix,omitempty"`
+}
+
+// Validate checks that the mapping only uses known terms and outputs.
+func (mapping *EnrichmentMapping) Validate() error {
+	for _, term := range mapping.Columns {
+		if err := checkEnum("enrichment term", term, containsString(EnrichmentTerms, term)); err != nil {
+			return err
+		}
+	}
+	for _, output := range mapping.Outputs {
+		if err := checkEnum("enrichment output", output, containsString(EnrichmentOutputs, output)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// OutputColumns returns the names of the columns appended to each record,
+// including the status columns.
+func (mapping *EnrichmentMapping) OutputColumns() []string {
+	outputs := mapping.Outputs
+	if len(outputs) == 0 {
+		outputs = defaultEnrichmentOutputs
+	}
+	prefix := mapping.Prefix
+	if prefix == "" {
+		prefix = "pipl_"
+	}
+	columns := make([]string, 0, len(outputs)+2)
+	for _, output := range outputs {
+		columns = append(columns, prefix+output)
+	}
+	return append(columns, EnrichmentStatusColumn, EnrichmentErrorColumn)
+}
+
+// SearchObject builds the search object for a record.
+func (mapping *EnrichmentMapping) SearchObject(record map[string]string) (*Person, error) {
+	terms := make(map[string]string)
+	columns := make([]string, 0, len(mapping.Columns))
+	for column := range mapping.Columns {
+		columns = append(columns, column)
+	}
+	sort.Strings(columns)
+	for _, column := range columns {
+		if value := strings.TrimSpace(record[column]); value != "" {
+			terms[mapping.Columns[column]] = joinNonEmpty(" ", terms[mapping.Columns[column]], value)
+		}
+	}
+
+	person := NewPerson()
+	if terms["name"] != "" {
+		person.AddNameRaw(terms["name"])
+	}
+	if terms["first_name"] != "" || terms["last_name"] != "" {
+		person.AddName(terms["first_name"], terms["middle_name"], terms["last_name"], "", "")
+	}
+	if terms["email"] != "" {
+		person.AddEmail(terms["email"])
+	}
+	if terms["phone"] != "" {
+		person.Phones = append(person.Phones, Phone{Raw: terms["phone"]})
+	}
+	if terms["username"] != "" {
+		person.AddUsername(terms["username"])
+	}
+	if terms["user_id"] != "" {
+		person.AddUserID(terms["user_id"])
+	}
+	if terms["url"] != "" {
+		person.AddURL(terms["url"])
+	}
+	if terms["address"] != "" {
+		person.AddAddressRaw(terms["address"])
+	}
+	if joinNonEmpty("", terms["house"], terms["street"], terms["city"], terms["state"], terms["zip_code"], terms["country"]) != "" {
+		person.Addresses = append(person.Addresses, Address{
+			House: terms["house"], Street: terms["street"], Apartment: terms["apartment"], City: terms["city"],
+			State: terms["state"], ZipCode: terms["zip_code"], Country: terms["country"],
+		})
+	}
+	if terms["dob"] != "" {
+		person.SetDateOfBirth(terms["dob"])
+	}
+	if terms["gender"] != "" {
+		if err := person.SetGender(GenderValue(strings.ToLower(terms["gender"]))); err != nil {
+			return nil, err
+		}
+	}
+	if terms["job_title"] != "" || terms["organization"] != "" {
+		person.AddJob(terms["job_title"], terms["organization"], "", "", "")
+	}
+	if !meetsMinimumCriteria(person) {
+		return nil, &ErrInsufficientSearch{}
+	}
+	if err := person.Validate(); err != nil {
+		return nil, err
+	}
+	return person, nil
+}
+
+// EnrichmentResult is the outcome of enriching a single record. Person is the
+// profile the record was matched to, if any, and Values holds the appended
+// columns.
+type EnrichmentResult struct {
+	Status   EnrichmentStatus
+	Err      error
+	Response *Response
+	Person   *Person
+	Values   map[string]string
+}
+
+// Enricher matches records from our own systems (CRM exports and the like)
+// against Pipl, and appends the fields of the matched profiles to them.
+type Enricher struct {
+	// Searcher runs the searches, typically a *Client
+	Searcher Searcher
+
+	// Mapping declares the search terms and outputs
+	Mapping EnrichmentMapping
+
+	// MinMatch is the match confidence a possible person needs for an ambiguous
+	// search to be resolved through its search pointer. Zero leaves ambiguous
+	// records unresolved.
+	MinMatch float32
+}
+
+// Enrich searches for a single record. Records that can't be searched get the
+// EnrichmentInvalid status, and failed searches EnrichmentError; neither is
+// returned as an error, so a batch carries on.
+func (enricher *Enricher) Enrich(record map[string]string) *EnrichmentResult {
+	result := &EnrichmentResult{Values: make(map[string]string)}
+	query, err := enricher.Mapping.SearchObject(record)
+	if err != nil {
+		result.Status, result.Err = EnrichmentInvalid, err
+		return enricher.finish(result)
+	}
+	response, err := enricher.Searcher.SearchByPerson(query)
+	if err != nil && response == nil {
+		result.Status, result.Err = EnrichmentError, err
+		return enricher.finish(result)
+	}
+	result.Response = response
+	if err := response.Err(); err != nil {
+		result.Status, result.Err = EnrichmentError, err
+		return enricher.finish(result)
+	}
+	best, complete := response.Best()
+	switch {
+	case best == nil:
+		result.Status = EnrichmentNoMatch
+	case complete:
+		result.Status, result.Person = EnrichmentOK, best
+	case enricher.MinMatch != 0 && best.Match >= enricher.MinMatch && best.SearchPointer != "":
+		person, err := enricher.Searcher.SearchByPointer(best.SearchPointer)
+		if err != nil {
+			result.Status, result.Err = EnrichmentError, err
+			break
+		}
+		if person.Match == 0 {
+			person.Match = best.Match
+		}
+		result.Status, result.Person = EnrichmentOK, person
+	default:
+		result.Status, result.Person = EnrichmentAmbiguous, best
+	}
+	return enricher.finish(result)
+}
+
+// finish fills in the output and status columns of a result.
+func (enricher *Enricher) finish(result *EnrichmentResult) *EnrichmentResult {
+	columns := enricher.Mapping.OutputColumns()
+	outputs := enricher.Mapping.Outputs
+	if len(outputs) == 0 {
+		outputs = defaultEnrichmentOutputs
+	}
+	for i, output := range outputs {
+		result.Values[columns[i]] = enrichmentValue(output, result)
+	}
+	result.Values[EnrichmentStatusColumn] = string(result.Status)
+	result.Values[EnrichmentErrorColumn] = ""
+	if result.Err != nil {
+		result.Values[EnrichmentErrorColumn] = result.Err.Error()
+	}
+	return result
+}
+
+// enrichmentValue renders one output. Ambiguous results only get the values
+// describing the search (match, search_id...), since the best possible person
+// may not be the right one.
+func enrichmentValue(output string, result *EnrichmentResult) string {
+	person := result.Person
+	switch output {
+	case "search_id":
+		if result.Response != nil {
+			return result.Response.SearchID
+		}
+		return ""
+	case "persons_count":
+		if result.Response != nil {
+			return strconv.Itoa(result.Response.PersonsCount)
+		}
+		return ""
+	case "match":
+		if person != nil {
+			return formatMatch(person.Match)
+		}
+		return ""
+	}
+	if person == nil || result.Status != EnrichmentOK {
+		return ""
+	}
+	switch output {
+	case "person_id":
+		return string(person.ID)
+	case "job_title", "organization":
+		job, _ := preferredItem(person.PersonFields.items(CategoryJobs)).(*Job)
+		if job == nil {
+			return ""
+		}
+		if output == "job_title" {
+			return job.Title
+		}
+		return job.Organization
+	}
+	category := FieldCategory(output + "s")
+	switch output {
+	case "gender", "dob":
+		category = FieldCategory(output)
+	case "address":
+		category = CategoryAddresses
+	}
+	if item := preferredItem(person.PersonFields.items(category)); item != nil {
+		return item.label()
+	}
+	return ""
+}
+
+// preferredItem returns the first current value, or the first value if none is
+// current.
+func preferredItem(items []fieldItem) fieldItem {
+	for _, item := range items {
+		if validity := validityOf(item); validity != nil && validity.Current {
+			return item
+		}
+	}
+	if len(items) > 0 {
+		return items[0]
+	}
+	return nil
+}
+
+// EnrichCSV reads records from a CSV file with a header row, enriches them and
+// writes them out with the output and status columns appended.
+func (enricher *Enricher) EnrichCSV(r io.Reader, w io.Writer) error {
+	if err := enricher.Mapping.Validate(); err != nil {
+		return err
+	}
+	in := csv.NewReader(r)
+	in.FieldsPerRecord = -1
+	header, err := in.Read()
+	if err != nil {
+		return err
+	}
+	out := csv.NewWriter(w)
+	columns := enricher.Mapping.OutputColumns()
+	out.Write(append(append([]string{}, header...), columns...))
+	for {
+		row, err := in.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		record := make(map[string]string, len(header))
+		for i, column := range header {
+			if i < len(row) {
+				record[column] = row[i]
+			}
+		}
+		result := enricher.Enrich(record)
+		for _, column := range columns {
+			row = append(row, result.Values[column])
+		}
+		if err := out.Write(row); err != nil {
+			return err
+		}
+	}
+	out.Flush()
+	return out.Error()
+}
+
+// EnrichJSONL reads records from a JSON Lines file (one object per line),
+// enriches them and writes them out with the output and status members added.
+// Members that aren't strings are searched in their JSON form.
+func (enricher *Enricher) EnrichJSONL(r io.Reader, w io.Writer) error {
+	if err := enricher.Mapping.Validate(); err != nil {
+		return err
+	}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	out := bufio.NewWriter(w)
+	for line := 1; scanner.Scan(); line++ {
+		data := bytes.TrimSpace(scanner.Bytes())
+		if len(data) == 0 {
+			continue
+		}
+		var members map[string]json.RawMessage
+		if err := json.Unmarshal(data, &members); err != nil {
+			return fmt.Errorf("line %d: %v", line, err)
+		}
+		result := enricher.Enrich(jsonRecord(members))
+		for column, value := range result.Values {
+			encoded, _ := json.Marshal(value)
+			members[column] = encoded
+		}
+		encoded, err := json.Marshal(members)
+		if err != nil {
+			return err
+		}
+		out.Write(encoded)
+		out.WriteByte('\n')
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	return out.Flush()
+}
+
+// jsonRecord converts the members of a JSON object into a record.
+func jsonRecord(members map[string]json.RawMessage) map[string]string {
+	record := make(map[string]string, len(members))
+	for name, raw := range members {
+		var value string
+		if err := json.Unmarshal(raw, &value); err == nil {
+			record[name] = value
+		} else if string(raw) != "null" {
+			record[name] = string(raw)
+		}
+	}
+	return record
+}
+
+// containsString reports whether values contains value.
+func containsString(values []string, value string) bool {
+	for _, candidate := range values {
+		if candidate == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,120 @@
+package pipl_test
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/xpcmdshell/pipl"
+)
+
+// responseSearcher answers searches with canned responses, keyed by email.
+type responseSearcher map[string]*pipl.Response
+
+func (searcher responseSearcher) SearchByPerson(searchObject *pipl.Person) (*pipl.Response, error) {
+	for _, email := range searchObject.Emails {
+		if response, ok := searcher[email.Address]; ok {
+			if response == nil {
+				return nil, errors.New("search failed")
+			}
+			return response, nil
+		}
+	}
+	return &pipl.Response{}, nil
+}
+
+func (searcher responseSearcher) SearchByPointer(searchPointer string) (*pipl.Person, error) {
+	return nil, errors.New("unexpected pointer search")
+}
+
+func newTestEnricher(t *testing.T) *pipl.Enricher {
+	person, _ := loadResponse(t, "person_response.json")
+	person.SearchID = "1001"
+	possible, _ := loadResponse(t, "possible_persons_response.json")
+	rejected, _ := loadResponse(t, "error_response.json")
+	return &pipl.Enricher{
+		Searcher: responseSearcher{
+			"clark@dailyplanet.example.com": person,
+			"kent@example.com":              possible,
+			"broken@example.com":            nil,
+			"rejected@example.com":          rejected,
+		},
+		Mapping: pipl.EnrichmentMapping{
+			Columns: map[string]string{"full_name": "name", "email": "email", "city": "city"},
+			Outputs: []string{"address", "job_title", "phone", "match", "search_id"},
+		},
+	}
+}
+
+func TestEnrichCSV(t *testing.T) {
+	input := "full_name,email,city\n" +
+		"Clark Kent,clark@dailyplanet.example.com,Smallville\n" +
+		"C. Kent,kent@example.com,\n" +
+		"Bruce Wayne,bruce@example.com,Gotham\n" +
+		",,Metropolis\n" +
+		",broken@example.com,\n" +
+		",rejected@example.com,\n"
+	var output bytes.Buffer
+	if err := newTestEnricher(t).EnrichCSV(strings.NewReader(input), &output); err != nil {
+		t.Fatal(err)
+	}
+	rows, err := csv.NewReader(&output).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedHeader := "full_name,email,city,pipl_address,pipl_job_title,pipl_phone,pipl_match,pipl_search_id,pipl_status,pipl_error"
+	if strings.Join(rows[0], ",") != expectedHeader {
+		t.Errorf("unexpected header: %v", rows[0])
+	}
+	clark := rows[1]
+	if clark[3] != "10-1 Hickory Lane, Smallville, Kansas" || clark[4] != "Field Reporter" || clark[5] != "+1 978-555-0145" || clark[7] != "1001" || clark[8] != "ok" {
+		t.Errorf("unexpected enrichment: %q", clark)
+	}
+	for i, status := range []string{"ok", "ambiguous", "no_match", "invalid", "error", "error"} {
+		if rows[i+1][8] != status {
+			t.Errorf("row %d: expected status %s, got %q", i+1, status, rows[i+1])
+		}
+	}
+	if ambiguous := rows[2]; ambiguous[3] != "" || ambiguous[6] != "0.72" {
+		t.Errorf("ambiguous rows should only get the match: %q", ambiguous)
+	}
+	if rows[4][9] == "" || rows[5][9] != "search failed" || rows[6][9] != "The API key is invalid or has exceeded its quota" {
+		t.Errorf("missing errors: %q %q %q", rows[4], rows[5], rows[6])
+	}
+}
+
+func TestEnrichJSONL(t *testing.T) {
+	input := `{"full_name": "Clark Kent", "email": "clark@dailyplanet.example.com", "id": 7}` + "\n\n" + `{"city": "Gotham"}` + "\n"
+	var output bytes.Buffer
+	if err := newTestEnricher(t).EnrichJSONL(strings.NewReader(input), &output); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(output.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines, got %q", output.String())
+	}
+	var record map[string]interface{}
+	if err := json.Unmarshal([]byte(lines[0]), &record); err != nil {
+		t.Fatal(err)
+	}
+	if record["pipl_status"] != "ok" || record["pipl_job_title"] != "Field Reporter" || record["id"] != 7.0 {
+		t.Errorf("unexpected record: %v", record)
+	}
+	if !strings.Contains(lines[1], `"pipl_status":"invalid"`) {
+		t.Errorf("unexpected record: %s", lines[1])
+	}
+}
+
+func TestEnrichmentMappingValidate(t *testing.T) {
+	mapping := pipl.EnrichmentMapping{Columns: map[string]string{"name": "full_name"}}
+	if err := mapping.Validate(); err == nil {
+		t.Error("expected an error for an unknown term")
+	}
+	mapping = pipl.EnrichmentMapping{Columns: map[string]string{"name": "name"}, Outputs: []string{"salary"}}
+	if err := mapping.Validate(); err == nil {
+		t.Error("expected an error for an unknown output")
+	}
+}
@@ -0,0 +1,195 @@
+package pipl
+
+// GenderValue is the content of a Gender record, one of GenderMale or GenderFemale.
+type GenderValue string
+
+// NameType describes how a name relates to the person, e.g. NameTypeMaiden.
+type NameType string
+
+// AddressType describes what an address is used for, e.g. AddressTypeHome.
+type AddressType string
+
+// PhoneType describes the kind of phone line, e.g. PhoneTypeMobile.
+type PhoneType string
+
+// EmailType describes what an email address is used for, e.g. EmailTypeWork.
+type EmailType string
+
+// RelationshipType describes the nature of a relationship, e.g. RelationshipTypeFamily.
+type RelationshipType string
+
+// RelationshipSubtype further describes a relationship (e.g. "Father", "Spouse").
+// Pipl returns it as free text, so there's no fixed set of values to check against.
+type RelationshipSubtype string
+
+const (
+	// GenderMale is the "male" gender value
+	GenderMale GenderValue = "male"
+
+	// GenderFemale is the "female" gender value
+	GenderFemale GenderValue = "female"
+
+	// NameTypePresent is the name a person currently goes by
+	NameTypePresent NameType = "present"
+
+	// NameTypeMaiden is a person's maiden name
+	NameTypeMaiden NameType = "maiden"
+
+	// NameTypeFormer is a name the person no longer goes by
+	NameTypeFormer NameType = "former"
+
+	// NameTypeAlias is an alternate name the person is known by
+	NameTypeAlias NameType = "alias"
+
+	// AddressTypeHome is a residential address
+	AddressTypeHome AddressType = "home"
+
+	// AddressTypeWork is a business address
+	AddressTypeWork AddressType = "work"
+
+	// AddressTypeOld is an address the person no longer uses
+	AddressTypeOld AddressType = "old"
+
+	// PhoneTypeMobile is a mobile phone
+	PhoneTypeMobile PhoneType = "mobile"
+
+	// PhoneTypeHomePhone is a residential landline
+	PhoneTypeHomePhone PhoneType = "home_phone"
+
+	// PhoneTypeHomeFax is a residential fax line
+	PhoneTypeHomeFax PhoneType = "home_fax"
+
+	// PhoneTypeWorkPhone is a business landline
+	PhoneTypeWorkPhone PhoneType = "work_phone"
+
+	// PhoneTypeWorkFax is a business fax line
+	PhoneTypeWorkFax PhoneType = "work_fax"
+
+	// PhoneTypePager is a pager
+	PhoneTypePager PhoneType = "pager"
+
+	// EmailTypePersonal is a personal email address
+	EmailTypePersonal EmailType = "personal"
+
+	// EmailTypeWork is a work email address
+	EmailTypeWork EmailType = "work"
+
+	// RelationshipTypeWork is a professional relationship
+	RelationshipTypeWork RelationshipType = "work"
+
+	// RelationshipTypeFamily is a family relationship
+	RelationshipTypeFamily RelationshipType = "family"
+
+	// RelationshipTypeFriend is a friendship (Pipl's default relationship type)
+	RelationshipTypeFriend RelationshipType = "friend"
+
+	// RelationshipTypeOther is any other kind of relationship
+	RelationshipTypeOther RelationshipType = "other"
+)
+
+// IsKnown reports whether the value is one of the documented gender values.
+func (gender GenderValue) IsKnown() bool {
+	switch gender {
+	case GenderMale, GenderFemale:
+		return true
+	}
+	return false
+}
+
+// IsKnown reports whether the value is one of the documented name types.
+func (nameType NameType) IsKnown() bool {
+	switch nameType {
+	case NameTypePresent, NameTypeMaiden, NameTypeFormer, NameTypeAlias:
+		return true
+	}
+	return false
+}
+
+// IsKnown reports whether the value is one of the documented address types.
+func (addressType AddressType) IsKnown() bool {
+	switch addressType {
+	case AddressTypeHome, AddressTypeWork, AddressTypeOld:
+		return true
+	}
+	return false
+}
+
+// IsKnown reports whether the value is one of the documented phone types.
+func (phoneType PhoneType) IsKnown() bool {
+	switch phoneType {
+	case PhoneTypeMobile, PhoneTypeHomePhone, PhoneTypeHomeFax, PhoneTypeWorkPhone, PhoneTypeWorkFax, PhoneTypePager:
+		return true
+	}
+	return false
+}
+
+// IsKnown reports whether the value is one of the documented email types.
+func (emailType EmailType) IsKnown() bool {
+	switch emailType {
+	case EmailTypePersonal, EmailTypeWork:
+		return true
+	}
+	return false
+}
+
+// IsKnown reports whether the value is one of the documented relationship types.
+func (relationshipType RelationshipType) IsKnown() bool {
+	switch relationshipType {
+	case RelationshipTypeWork, RelationshipTypeFamily, RelationshipTypeFriend, RelationshipTypeOther:
+		return true
+	}
+	return false
+}
+
+// IsKnown always reports true for non-empty subtypes, since Pipl doesn't
+// restrict them to a fixed set.
+func (subtype RelationshipSubtype) IsKnown() bool {
+	return subtype != ""
+}
+
+// checkEnum returns an ErrInvalidValue if value is set but not known. Empty
+// values are always accepted, since they're simply omitted from searches.
+func checkEnum(field string, value string, known bool) error {
+	if value == "" || known {
+		return nil
+	}
+	return &ErrInvalidValue{Field: field, Value: value}
+}
+
+// Validate checks the typed values set on a search object (gender, name,
+// address, phone, email and relationship types) and returns an ErrInvalidValue
+// for the first one that isn't documented by Pipl. Values returned by the API
+// are never validated, so unknown values in responses are kept as-is.
+func (searchObject *Person) Validate() error {
+	if searchObject.Gender != nil {
+		if err := checkEnum("gender", string(searchObject.Gender.Content), searchObject.Gender.Content.IsKnown()); err != nil {
+			return err
+		}
+	}
+	for _, name := range searchObject.Names {
+		if err := checkEnum("name type", string(name.Type), name.Type.IsKnown()); err != nil {
+			return err
+		}
+	}
+	for _, address := range searchObject.Addresses {
+		if err := checkEnum("address type", string(address.Type), address.Type.IsKnown()); err != nil {
+			return err
+		}
+	}
+	for _, phone := range searchObject.Phones {
+		if err := checkEnum("phone type", string(phone.Type), phone.Type.IsKnown()); err != nil {
+			return err
+		}
+	}
+	for _, email := range searchObject.Emails {
+		if err := checkEnum("email type", string(email.Type), email.Type.IsKnown()); err != nil {
+			return err
+		}
+	}
+	for _, relationship := range searchObject.Relationships {
+		if err := checkEnum("relationship type", string(relationship.Type), relationship.Type.IsKnown()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package pipl_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/xpcmdshell/pipl"
+)
+
+func TestSetGenderRejectsUnknownValues(t *testing.T) {
+	searchObject := pipl.NewPerson()
+	err := searchObject.SetGender("m")
+	if _, ok := err.(*pipl.ErrInvalidValue); !ok {
+		t.Fatalf("expected ErrInvalidValue, got %v", err)
+	}
+	if searchObject.Gender != nil {
+		t.Error("gender should not be set after a failed SetGender")
+	}
+	if err := searchObject.SetGender(pipl.GenderFemale); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestValidateTypedFields(t *testing.T) {
+	searchObject := pipl.NewPerson()
+	searchObject.AddEmail("clark.kent@example.com")
+	if err := searchObject.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if err := searchObject.AddPhoneWithType(5555550100, "cell"); err == nil {
+		t.Error("expected an error for an unknown phone type")
+	}
+	if err := searchObject.AddRelationship(pipl.Relationship{Type: "enemy"}); err == nil {
+		t.Error("expected an error for an unknown relationship type")
+	}
+	if err := searchObject.AddNameWithType("Lois", "", "Lane", "", "", "married"); err == nil || len(searchObject.Names) != 0 {
+		t.Error("expected an error for an unknown name type")
+	}
+	if err := searchObject.AddNameWithType("Lois", "", "Lane", "", "", pipl.NameTypeMaiden); err != nil || searchObject.Names[0].Type != pipl.NameTypeMaiden {
+		t.Errorf("unexpected name: %v %+v", err, searchObject.Names)
+	}
+	if err := searchObject.AddAddressWithType("10", "Hickory Lane", "", "Smallville", "KS", "US", "", pipl.AddressTypeHome); err != nil {
+		t.Fatal(err)
+	}
+	if err := searchObject.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if err := searchObject.AddAddressWithType("", "", "", "Gotham", "", "US", "", "vacation"); err == nil || len(searchObject.Addresses) != 1 {
+		t.Error("expected an error for an unknown address type")
+	}
+	searchObject.Addresses = append(searchObject.Addresses, pipl.Address{Type: "vacation"})
+	if err := searchObject.Validate(); err == nil {
+		t.Error("expected Validate to catch the unknown address type")
+	}
+}
+
+func TestUnknownEnumValuesArePreserved(t *testing.T) {
+	in := `{"@type":"secondary","address":"clark@example.com"}`
+	var email pipl.Email
+	if err := json.Unmarshal([]byte(in), &email); err != nil {
+		t.Fatal(err)
+	}
+	if email.Type.IsKnown() || email.Type != "secondary" {
+		t.Errorf("unexpected email type %q", email.Type)
+	}
+	out, _ := json.Marshal(email)
+	if string(out) != in {
+		t.Errorf("got %s, want %s", out, in)
+	}
+}
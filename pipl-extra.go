@@ -0,0 +1,405 @@
+package pipl
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Every model type in pipl-def.go carries an Extra map holding the attributes
+// that the library doesn't know about yet. They're captured when a response is
+// decoded and written back out when the value is marshalled again, so stored
+// profiles don't lose data when Pipl adds new fields.
+//
+// The marshalling methods below all follow the same pattern: convert the value
+// to a local type with the same fields but no methods (so encoding/json falls
+// back to its default behaviour) and let unmarshalWithExtra/marshalWithExtra
+// deal with the unknown attributes.
+
+// knownFieldCache maps a struct type to the set of JSON keys it decodes.
+var knownFieldCache sync.Map
+
+// knownFields returns the JSON keys that encoding/json maps onto fields of
+// structType, including those promoted from embedded structs.
+func knownFields(structType reflect.Type) map[string]bool {
+	if cached, ok := knownFieldCache.Load(structType); ok {
+		return cached.(map[string]bool)
+	}
+	fields := make(map[string]bool)
+	collectKnownFields(structType, fields)
+	knownFieldCache.Store(structType, fields)
+	return fields
+}
+
+func collectKnownFields(structType reflect.Type, fields map[string]bool) {
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name := strings.Split(tag, ",")[0]
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Ptr {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				collectKnownFields(embedded, fields)
+				continue
+			}
+		}
+		if field.PkgPath != "" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		fields[name] = true
+	}
+}
+
+// unmarshalWithExtra decodes data into value (a pointer to a method-less copy
+// of a model type) and stores any attributes that value doesn't know about in
+// extra. extra is left nil if there aren't any.
+func unmarshalWithExtra(data []byte, value interface{}, extra *map[string]json.RawMessage) error {
+	if err := json.Unmarshal(data, value); err != nil {
+		return err
+	}
+	var attributes map[string]json.RawMessage
+	if err := json.Unmarshal(data, &attributes); err != nil {
+		return err
+	}
+	known := knownFields(reflect.TypeOf(value).Elem())
+	*extra = nil
+	for key, raw := range attributes {
+		if known[key] {
+			continue
+		}
+		if *extra == nil {
+			*extra = make(map[string]json.RawMessage)
+		}
+		(*extra)[key] = raw
+	}
+	return nil
+}
+
+// marshalWithExtra encodes value (a method-less copy of a model type) and
+// appends the attributes in extra, in key order. Keys that value already
+// encodes itself are skipped, so Extra can never produce duplicate keys.
+func marshalWithExtra(value interface{}, extra map[string]json.RawMessage) ([]byte, error) {
+	data, err := json.Marshal(value)
+	if err != nil || len(extra) == 0 {
+		return data, err
+	}
+	known := knownFields(reflect.TypeOf(value))
+	keys := make([]string, 0, len(extra))
+	for key := range extra {
+		if !known[key] {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	var buffer bytes.Buffer
+	buffer.Write(data[:len(data)-1])
+	empty := len(bytes.TrimSpace(data[1:len(data)-1])) == 0
+	for _, key := range keys {
+		if !empty {
+			buffer.WriteByte(',')
+		}
+		empty = false
+		encodedKey, err := json.Marshal(key)
+		if err != nil {
+			return nil, err
+		}
+		buffer.Write(encodedKey)
+		buffer.WriteByte(':')
+		if len(extra[key]) == 0 {
+			buffer.WriteString("null")
+		} else {
+			buffer.Write(extra[key])
+		}
+	}
+	buffer.WriteByte('}')
+	return buffer.Bytes(), nil
+}
+
+// UnmarshalJSON decodes a Name, keeping unknown attributes in Extra.
+func (name *Name) UnmarshalJSON(data []byte) error {
+	type plain Name
+	return unmarshalWithExtra(data, (*plain)(name), &name.Extra)
+}
+
+// MarshalJSON encodes a Name, including any attributes kept in Extra.
+func (name Name) MarshalJSON() ([]byte, error) {
+	type plain Name
+	return marshalWithExtra(plain(name), name.Extra)
+}
+
+// UnmarshalJSON decodes a Address, keeping unknown attributes in Extra.
+func (address *Address) UnmarshalJSON(data []byte) error {
+	type plain Address
+	return unmarshalWithExtra(data, (*plain)(address), &address.Extra)
+}
+
+// MarshalJSON encodes a Address, including any attributes kept in Extra.
+func (address Address) MarshalJSON() ([]byte, error) {
+	type plain Address
+	return marshalWithExtra(plain(address), address.Extra)
+}
+
+// UnmarshalJSON decodes a Phone, keeping unknown attributes in Extra.
+func (phone *Phone) UnmarshalJSON(data []byte) error {
+	type plain Phone
+	return unmarshalWithExtra(data, (*plain)(phone), &phone.Extra)
+}
+
+// MarshalJSON encodes a Phone, including any attributes kept in Extra.
+func (phone Phone) MarshalJSON() ([]byte, error) {
+	type plain Phone
+	return marshalWithExtra(plain(phone), phone.Extra)
+}
+
+// UnmarshalJSON decodes a Email, keeping unknown attributes in Extra.
+func (email *Email) UnmarshalJSON(data []byte) error {
+	type plain Email
+	return unmarshalWithExtra(data, (*plain)(email), &email.Extra)
+}
+
+// MarshalJSON encodes a Email, including any attributes kept in Extra.
+func (email Email) MarshalJSON() ([]byte, error) {
+	type plain Email
+	return marshalWithExtra(plain(email), email.Extra)
+}
+
+// UnmarshalJSON decodes a Username, keeping unknown attributes in Extra.
+func (username *Username) UnmarshalJSON(data []byte) error {
+	type plain Username
+	return unmarshalWithExtra(data, (*plain)(username), &username.Extra)
+}
+
+// MarshalJSON encodes a Username, including any attributes kept in Extra.
+func (username Username) MarshalJSON() ([]byte, error) {
+	type plain Username
+	return marshalWithExtra(plain(username), username.Extra)
+}
+
+// UnmarshalJSON decodes a UserID, keeping unknown attributes in Extra.
+func (userID *UserID) UnmarshalJSON(data []byte) error {
+	type plain UserID
+	return unmarshalWithExtra(data, (*plain)(userID), &userID.Extra)
+}
+
+// MarshalJSON encodes a UserID, including any attributes kept in Extra.
+func (userID UserID) MarshalJSON() ([]byte, error) {
+	type plain UserID
+	return marshalWithExtra(plain(userID), userID.Extra)
+}
+
+// UnmarshalJSON decodes a DateRange, keeping unknown attributes in Extra.
+func (dateRange *DateRange) UnmarshalJSON(data []byte) error {
+	type plain DateRange
+	return unmarshalWithExtra(data, (*plain)(dateRange), &dateRange.Extra)
+}
+
+// MarshalJSON encodes a DateRange, including any attributes kept in Extra.
+func (dateRange DateRange) MarshalJSON() ([]byte, error) {
+	type plain DateRange
+	return marshalWithExtra(plain(dateRange), dateRange.Extra)
+}
+
+// UnmarshalJSON decodes a DateOfBirth, keeping unknown attributes in Extra.
+func (dob *DateOfBirth) UnmarshalJSON(data []byte) error {
+	type plain DateOfBirth
+	return unmarshalWithExtra(data, (*plain)(dob), &dob.Extra)
+}
+
+// MarshalJSON encodes a DateOfBirth, including any attributes kept in Extra.
+func (dob DateOfBirth) MarshalJSON() ([]byte, error) {
+	type plain DateOfBirth
+	return marshalWithExtra(plain(dob), dob.Extra)
+}
+
+// UnmarshalJSON decodes a Image, keeping unknown attributes in Extra.
+func (image *Image) UnmarshalJSON(data []byte) error {
+	type plain Image
+	return unmarshalWithExtra(data, (*plain)(image), &image.Extra)
+}
+
+// MarshalJSON encodes a Image, including any attributes kept in Extra.
+func (image Image) MarshalJSON() ([]byte, error) {
+	type plain Image
+	return marshalWithExtra(plain(image), image.Extra)
+}
+
+// UnmarshalJSON decodes a Job, keeping unknown attributes in Extra.
+func (job *Job) UnmarshalJSON(data []byte) error {
+	type plain Job
+	return unmarshalWithExtra(data, (*plain)(job), &job.Extra)
+}
+
+// MarshalJSON encodes a Job, including any attributes kept in Extra.
+func (job Job) MarshalJSON() ([]byte, error) {
+	type plain Job
+	return marshalWithExtra(plain(job), job.Extra)
+}
+
+// UnmarshalJSON decodes a Education, keeping unknown attributes in Extra.
+func (education *Education) UnmarshalJSON(data []byte) error {
+	type plain Education
+	return unmarshalWithExtra(data, (*plain)(education), &education.Extra)
+}
+
+// MarshalJSON encodes a Education, including any attributes kept in Extra.
+func (education Education) MarshalJSON() ([]byte, error) {
+	type plain Education
+	return marshalWithExtra(plain(education), education.Extra)
+}
+
+// UnmarshalJSON decodes a Gender, keeping unknown attributes in Extra.
+func (gender *Gender) UnmarshalJSON(data []byte) error {
+	type plain Gender
+	return unmarshalWithExtra(data, (*plain)(gender), &gender.Extra)
+}
+
+// MarshalJSON encodes a Gender, including any attributes kept in Extra.
+func (gender Gender) MarshalJSON() ([]byte, error) {
+	type plain Gender
+	return marshalWithExtra(plain(gender), gender.Extra)
+}
+
+// UnmarshalJSON decodes a Ethnicity, keeping unknown attributes in Extra.
+func (ethnicity *Ethnicity) UnmarshalJSON(data []byte) error {
+	type plain Ethnicity
+	return unmarshalWithExtra(data, (*plain)(ethnicity), &ethnicity.Extra)
+}
+
+// MarshalJSON encodes a Ethnicity, including any attributes kept in Extra.
+func (ethnicity Ethnicity) MarshalJSON() ([]byte, error) {
+	type plain Ethnicity
+	return marshalWithExtra(plain(ethnicity), ethnicity.Extra)
+}
+
+// UnmarshalJSON decodes a Language, keeping unknown attributes in Extra.
+func (language *Language) UnmarshalJSON(data []byte) error {
+	type plain Language
+	return unmarshalWithExtra(data, (*plain)(language), &language.Extra)
+}
+
+// MarshalJSON encodes a Language, including any attributes kept in Extra.
+func (language Language) MarshalJSON() ([]byte, error) {
+	type plain Language
+	return marshalWithExtra(plain(language), language.Extra)
+}
+
+// UnmarshalJSON decodes a OriginCountry, keeping unknown attributes in Extra.
+func (originCountry *OriginCountry) UnmarshalJSON(data []byte) error {
+	type plain OriginCountry
+	return unmarshalWithExtra(data, (*plain)(originCountry), &originCountry.Extra)
+}
+
+// MarshalJSON encodes a OriginCountry, including any attributes kept in Extra.
+func (originCountry OriginCountry) MarshalJSON() ([]byte, error) {
+	type plain OriginCountry
+	return marshalWithExtra(plain(originCountry), originCountry.Extra)
+}
+
+// UnmarshalJSON decodes a Relationship, keeping unknown attributes in Extra.
+func (relationship *Relationship) UnmarshalJSON(data []byte) error {
+	type plain Relationship
+	return unmarshalWithExtra(data, (*plain)(relationship), &relationship.Extra)
+}
+
+// MarshalJSON encodes a Relationship, including any attributes kept in Extra.
+func (relationship Relationship) MarshalJSON() ([]byte, error) {
+	type plain Relationship
+	return marshalWithExtra(plain(relationship), relationship.Extra)
+}
+
+// UnmarshalJSON decodes a URL, keeping unknown attributes in Extra.
+func (url *URL) UnmarshalJSON(data []byte) error {
+	type plain URL
+	return unmarshalWithExtra(data, (*plain)(url), &url.Extra)
+}
+
+// MarshalJSON encodes a URL, including any attributes kept in Extra.
+func (url URL) MarshalJSON() ([]byte, error) {
+	type plain URL
+	return marshalWithExtra(plain(url), url.Extra)
+}
+
+// UnmarshalJSON decodes a Tag, keeping unknown attributes in Extra.
+func (tag *Tag) UnmarshalJSON(data []byte) error {
+	type plain Tag
+	return unmarshalWithExtra(data, (*plain)(tag), &tag.Extra)
+}
+
+// MarshalJSON encodes a Tag, including any attributes kept in Extra.
+func (tag Tag) MarshalJSON() ([]byte, error) {
+	type plain Tag
+	return marshalWithExtra(plain(tag), tag.Extra)
+}
+
+// UnmarshalJSON decodes a Person, keeping unknown attributes in Extra.
+func (person *Person) UnmarshalJSON(data []byte) error {
+	type plain Person
+	return unmarshalWithExtra(data, (*plain)(person), &person.Extra)
+}
+
+// MarshalJSON encodes a Person, including any attributes kept in Extra.
+func (person Person) MarshalJSON() ([]byte, error) {
+	type plain Person
+	return marshalWithExtra(plain(person), person.Extra)
+}
+
+// UnmarshalJSON decodes a Source, keeping unknown attributes in Extra.
+func (source *Source) UnmarshalJSON(data []byte) error {
+	type plain Source
+	return unmarshalWithExtra(data, (*plain)(source), &source.Extra)
+}
+
+// MarshalJSON encodes a Source, including any attributes kept in Extra.
+func (source Source) MarshalJSON() ([]byte, error) {
+	type plain Source
+	return marshalWithExtra(plain(source), source.Extra)
+}
+
+// UnmarshalJSON decodes a FieldCount, keeping unknown attributes in Extra.
+func (fieldCount *FieldCount) UnmarshalJSON(data []byte) error {
+	type plain FieldCount
+	return unmarshalWithExtra(data, (*plain)(fieldCount), &fieldCount.Extra)
+}
+
+// MarshalJSON encodes a FieldCount, including any attributes kept in Extra.
+func (fieldCount FieldCount) MarshalJSON() ([]byte, error) {
+	type plain FieldCount
+	return marshalWithExtra(plain(fieldCount), fieldCount.Extra)
+}
+
+// UnmarshalJSON decodes a AvailableData, keeping unknown attributes in Extra.
+func (availableData *AvailableData) UnmarshalJSON(data []byte) error {
+	type plain AvailableData
+	return unmarshalWithExtra(data, (*plain)(availableData), &availableData.Extra)
+}
+
+// MarshalJSON encodes a AvailableData, including any attributes kept in Extra.
+func (availableData AvailableData) MarshalJSON() ([]byte, error) {
+	type plain AvailableData
+	return marshalWithExtra(plain(availableData), availableData.Extra)
+}
+
+// UnmarshalJSON decodes a Response, keeping unknown attributes in Extra.
+func (response *Response) UnmarshalJSON(data []byte) error {
+	type plain Response
+	return unmarshalWithExtra(data, (*plain)(response), &response.Extra)
+}
+
+// MarshalJSON encodes a Response, including any attributes kept in Extra.
+func (response Response) MarshalJSON() ([]byte, error) {
+	type plain Response
+	return marshalWithExtra(plain(response), response.Extra)
+}
@@ -0,0 +1,578 @@
+package pipl
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// FieldCategory identifies one of the data fields in PersonFields. The values
+// match the JSON keys used by the Pipl API.
+type FieldCategory string
+
+const (
+	// CategoryNames is the category of Names
+	CategoryNames FieldCategory = "names"
+
+	// CategoryEmails is the category of Emails
+	CategoryEmails FieldCategory = "emails"
+
+	// CategoryUsernames is the category of Usernames
+	CategoryUsernames FieldCategory = "usernames"
+
+	// CategoryPhones is the category of Phones
+	CategoryPhones FieldCategory = "phones"
+
+	// CategoryGender is the category of Gender
+	CategoryGender FieldCategory = "gender"
+
+	// CategoryDateOfBirth is the category of DateOfBirth
+	CategoryDateOfBirth FieldCategory = "dob"
+
+	// CategoryLanguages is the category of Languages
+	CategoryLanguages FieldCategory = "languages"
+
+	// CategoryEthnicities is the category of Ethnicities
+	CategoryEthnicities FieldCategory = "ethnicities"
+
+	// CategoryOriginCountries is the category of OriginCountries
+	CategoryOriginCountries FieldCategory = "origin_countries"
+
+	// CategoryAddresses is the category of Addresses
+	CategoryAddresses FieldCategory = "addresses"
+
+	// CategoryJobs is the category of Jobs
+	CategoryJobs FieldCategory = "jobs"
+
+	// CategoryEducations is the category of Educations
+	CategoryEducations FieldCategory = "educations"
+
+	// CategoryImages is the category of Images
+	CategoryImages FieldCategory = "images"
+
+	// CategoryUserIDs is the category of UserIDs
+	CategoryUserIDs FieldCategory = "user_ids"
+
+	// CategoryURLs is the category of URLs
+	CategoryURLs FieldCategory = "urls"
+
+	// CategoryRelationships is the category of Relationships
+	CategoryRelationships FieldCategory = "relationships"
+
+	// CategoryTags is the category of Tags
+	CategoryTags FieldCategory = "tags"
+)
+
+// FieldCategories lists every field category in the order the fields appear
+// in PersonFields.
+var FieldCategories = []FieldCategory{
+	CategoryNames, CategoryEmails, CategoryUsernames, CategoryPhones, CategoryGender,
+	CategoryDateOfBirth, CategoryLanguages, CategoryEthnicities, CategoryOriginCountries,
+	CategoryAddresses, CategoryJobs, CategoryEducations, CategoryImages, CategoryUserIDs,
+	CategoryURLs, CategoryRelationships, CategoryTags,
+}
+
+// fieldItem is implemented by every value held in PersonFields. key returns a
+// normalised form of the value used to decide whether two values are the same
+// (e.g. two spellings of a phone number), and label a short human-readable form.
+type fieldItem interface {
+	key() string
+	label() string
+}
+
+// personField describes where a category lives in PersonFields.
+type personField struct {
+	category FieldCategory
+	index    int
+	single   bool // a pointer to a single value (gender, dob) rather than a slice
+}
+
+// personFieldIndex maps each category onto its PersonFields field.
+var personFieldIndex = func() map[FieldCategory]personField {
+	fields := make(map[FieldCategory]personField)
+	fieldsType := reflect.TypeOf(PersonFields{})
+	for i := 0; i < fieldsType.NumField(); i++ {
+		field := fieldsType.Field(i)
+		category := FieldCategory(strings.Split(field.Tag.Get("json"), ",")[0])
+		fields[category] = personField{category: category, index: i, single: field.Type.Kind() == reflect.Ptr}
+	}
+	return fields
+}()
+
+// items returns pointers to every value of a category, so callers can inspect
+// or modify them in place. Gender and DateOfBirth yield at most one item.
+func (fields *PersonFields) items(category FieldCategory) []fieldItem {
+	info, ok := personFieldIndex[category]
+	if !ok {
+		return nil
+	}
+	value := reflect.ValueOf(fields).Elem().Field(info.index)
+	if info.single {
+		if value.IsNil() {
+			return nil
+		}
+		return []fieldItem{value.Interface().(fieldItem)}
+	}
+	items := make([]fieldItem, 0, value.Len())
+	for i := 0; i < value.Len(); i++ {
+		items = append(items, value.Index(i).Addr().Interface().(fieldItem))
+	}
+	return items
+}
+
+// setItems replaces the values of a category. items must hold pointers of the
+// category's type, as returned by items.
+func (fields *PersonFields) setItems(category FieldCategory, items []fieldItem) {
+	info, ok := personFieldIndex[category]
+	if !ok {
+		return
+	}
+	value := reflect.ValueOf(fields).Elem().Field(info.index)
+	if info.single {
+		if len(items) == 0 {
+			value.Set(reflect.Zero(value.Type()))
+		} else {
+			value.Set(reflect.ValueOf(items[0]))
+		}
+		return
+	}
+	if len(items) == 0 {
+		value.Set(reflect.Zero(value.Type()))
+		return
+	}
+	slice := reflect.MakeSlice(value.Type(), 0, len(items))
+	for _, item := range items {
+		slice = reflect.Append(slice, reflect.ValueOf(item).Elem())
+	}
+	value.Set(slice)
+}
+
+// clone returns a copy of the fields that shares no slices or pointers with
+// the original, so either can be modified without affecting the other.
+func (fields PersonFields) clone() PersonFields {
+	copied := PersonFields{}
+	for _, category := range FieldCategories {
+		items := fields.items(category)
+		cloned := make([]fieldItem, 0, len(items))
+		for _, item := range items {
+			cloned = append(cloned, cloneItem(item))
+		}
+		copied.setItems(category, cloned)
+	}
+	return copied
+}
+
+// cloneItem copies a single value, including the nested fields of a relationship.
+func cloneItem(item fieldItem) fieldItem {
+	copied := reflect.New(reflect.TypeOf(item).Elem())
+	copied.Elem().Set(reflect.ValueOf(item).Elem())
+	if relationship, ok := copied.Interface().(*Relationship); ok {
+		relationship.PersonFields = relationship.PersonFields.clone()
+	}
+	return copied.Interface().(fieldItem)
+}
+
+// validityOf returns the Validity embedded in an item, or nil if it has none.
+func validityOf(item fieldItem) *Validity {
+	if holder, ok := item.(interface{ validityRef() *Validity }); ok {
+		return holder.validityRef()
+	}
+	return nil
+}
+
+func (validity *Validity) validityRef() *Validity {
+	return validity
+}
+
+// strongerThan reports whether validity should be preferred over other: current
+// data beats stale data, then more recently seen data wins, then observed data
+// beats inferred data.
+func (validity Validity) strongerThan(other Validity) bool {
+	if validity.Current != other.Current {
+		return validity.Current
+	}
+	seen, otherSeen := validity.LastSeen.Time(), other.LastSeen.Time()
+	if !seen.Equal(otherSeen) {
+		return seen.After(otherSeen)
+	}
+	if validity.Inferred != other.Inferred {
+		return !validity.Inferred
+	}
+	return false
+}
+
+// normalize lowercases s and reduces it to letters and digits separated by
+// single spaces, so "Hickory Ln." and "hickory ln" compare equal.
+func normalize(s string) string {
+	return strings.Join(strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}), " ")
+}
+
+// digits strips everything but digits from s.
+func digits(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, s)
+}
+
+// joinNonEmpty joins the non-empty parts with sep.
+func joinNonEmpty(sep string, parts ...string) string {
+	kept := make([]string, 0, len(parts))
+	for _, part := range parts {
+		if part = strings.TrimSpace(part); part != "" {
+			kept = append(kept, part)
+		}
+	}
+	return strings.Join(kept, sep)
+}
+
+func (name Name) key() string {
+	if name.First != "" || name.Last != "" {
+		return normalize(joinNonEmpty(" ", name.First, name.Middle, name.Last))
+	}
+	return normalize(firstNonEmpty(name.Raw, name.Display))
+}
+
+func (name Name) label() string {
+	return firstNonEmpty(name.Display, joinNonEmpty(" ", name.Prefix, name.First, name.Middle, name.Last, name.Suffix), name.Raw)
+}
+
+func (email Email) key() string {
+	if email.Address != "" {
+		return strings.ToLower(strings.TrimSpace(email.Address))
+	}
+	return strings.ToLower(email.AddressMD5)
+}
+
+func (email Email) label() string {
+	return firstNonEmpty(email.Address, email.AddressMD5)
+}
+
+func (username Username) key() string {
+	return strings.ToLower(strings.TrimSpace(username.Content))
+}
+
+func (username Username) label() string {
+	return username.Content
+}
+
+func (userID UserID) key() string {
+	return strings.ToLower(strings.TrimSpace(userID.Content))
+}
+
+func (userID UserID) label() string {
+	return userID.Content
+}
+
+// key reduces the phone to its digits, so a number given as Number and as text
+// (e.g. Raw "978-555-0145") gets the same key. The country code is only part
+// of the key when it is known, and leading zeros (trunk or international
+// prefixes) are dropped from text.
+func (phone Phone) key() string {
+	number, extension := "", ""
+	if phone.Number != 0 {
+		number = strconv.Itoa(phone.Number)
+		if phone.CountryCode != 0 {
+			number = strconv.Itoa(phone.CountryCode) + number
+		}
+	} else {
+		text := strings.ToLower(firstNonEmpty(phone.Raw, phone.DisplayInternational, phone.Display))
+		if i := strings.IndexByte(text, 'x'); i >= 0 {
+			text, extension = text[:i], digits(text[i:])
+		}
+		number = strings.TrimLeft(digits(text), "0")
+	}
+	if phone.Extension != 0 {
+		extension = strconv.Itoa(phone.Extension)
+	}
+	if extension != "" {
+		number += "x" + extension
+	}
+	return number
+}
+
+func (phone Phone) label() string {
+	if phone.DisplayInternational != "" || phone.Display != "" || phone.Raw != "" {
+		return firstNonEmpty(phone.DisplayInternational, phone.Display, phone.Raw)
+	}
+	if phone.Number == 0 {
+		return ""
+	}
+	label := strconv.Itoa(phone.Number)
+	if phone.CountryCode != 0 {
+		label = "+" + strconv.Itoa(phone.CountryCode) + " " + label
+	}
+	if phone.Extension != 0 {
+		label += " x" + strconv.Itoa(phone.Extension)
+	}
+	return label
+}
+
+func (gender Gender) key() string {
+	return strings.ToLower(string(gender.Content))
+}
+
+func (gender Gender) label() string {
+	return string(gender.Content)
+}
+
+func (dob DateOfBirth) key() string {
+	if dob.DateRange != nil && (dob.DateRange.Start != "" || dob.DateRange.End != "") {
+		return string(dob.DateRange.Start) + "/" + string(dob.DateRange.End)
+	}
+	return normalize(dob.Display)
+}
+
+func (dob DateOfBirth) label() string {
+	if dob.Display != "" {
+		return dob.Display
+	}
+	if dob.DateRange != nil {
+		return dob.DateRange.label()
+	}
+	return ""
+}
+
+func (dateRange DateRange) label() string {
+	if dateRange.Start == dateRange.End {
+		return string(dateRange.Start)
+	}
+	return joinNonEmpty(" - ", string(dateRange.Start), string(dateRange.End))
+}
+
+func (language Language) key() string {
+	return strings.ToLower(joinNonEmpty("_", language.Language, language.Region))
+}
+
+func (language Language) label() string {
+	return firstNonEmpty(language.Display, joinNonEmpty("_", language.Language, language.Region))
+}
+
+func (ethnicity Ethnicity) key() string {
+	return normalize(ethnicity.Content)
+}
+
+func (ethnicity Ethnicity) label() string {
+	return ethnicity.Content
+}
+
+func (originCountry OriginCountry) key() string {
+	return strings.ToUpper(strings.TrimSpace(originCountry.Country))
+}
+
+func (originCountry OriginCountry) label() string {
+	return originCountry.Country
+}
+
+func (address Address) key() string {
+	parts := joinNonEmpty("|", address.Country, address.State, address.City, address.Street, address.House, address.Apartment, address.ZipCode, address.POBox)
+	if parts != "" {
+		return normalize(parts)
+	}
+	return normalize(firstNonEmpty(address.Raw, address.Display))
+}
+
+func (address Address) label() string {
+	if address.Display != "" || address.Raw != "" {
+		return firstNonEmpty(address.Display, address.Raw)
+	}
+	street := joinNonEmpty(" ", address.House, address.Street)
+	if address.Apartment != "" {
+		street = joinNonEmpty(" ", street, "#"+address.Apartment)
+	}
+	if address.POBox != "" {
+		street = joinNonEmpty(", ", street, "PO Box "+address.POBox)
+	}
+	return joinNonEmpty(", ", street, address.City, joinNonEmpty(" ", address.State, address.ZipCode), address.Country)
+}
+
+func (job Job) key() string {
+	if job.Title != "" || job.Organization != "" {
+		return normalize(job.Title) + "|" + normalize(job.Organization)
+	}
+	return normalize(job.Display)
+}
+
+func (job Job) label() string {
+	if job.Display != "" {
+		return job.Display
+	}
+	if job.Title != "" && job.Organization != "" {
+		return job.Title + " at " + job.Organization
+	}
+	return firstNonEmpty(job.Title, job.Organization, job.Industry)
+}
+
+func (education Education) key() string {
+	if education.Degree != "" || education.School != "" {
+		return normalize(education.Degree) + "|" + normalize(education.School)
+	}
+	return normalize(education.Display)
+}
+
+func (education Education) label() string {
+	if education.Display != "" {
+		return education.Display
+	}
+	if education.Degree != "" && education.School != "" {
+		return education.Degree + " from " + education.School
+	}
+	return firstNonEmpty(education.Degree, education.School)
+}
+
+func (image Image) key() string {
+	return firstNonEmpty(strings.TrimSpace(image.URL), image.ThumbnailToken)
+}
+
+func (image Image) label() string {
+	return image.URL
+}
+
+func (url URL) key() string {
+	return strings.TrimSuffix(strings.ToLower(strings.TrimSpace(url.URL)), "/")
+}
+
+func (url URL) label() string {
+	return url.URL
+}
+
+// key identifies a relationship by the relative's most distinctive data: a
+// name, or failing that an email, phone or username.
+func (relationship Relationship) key() string {
+	for _, category := range []FieldCategory{CategoryNames, CategoryEmails, CategoryPhones, CategoryUsernames, CategoryUserIDs} {
+		for _, item := range relationship.PersonFields.items(category) {
+			if key := item.key(); key != "" {
+				return string(category) + ":" + key
+			}
+		}
+	}
+	return ""
+}
+
+func (relationship Relationship) label() string {
+	for _, category := range []FieldCategory{CategoryNames, CategoryEmails, CategoryPhones, CategoryUsernames, CategoryUserIDs} {
+		for _, item := range relationship.PersonFields.items(category) {
+			if label := item.label(); label != "" {
+				return label
+			}
+		}
+	}
+	return ""
+}
+
+func (tag Tag) key() string {
+	return strings.ToLower(tag.Classification) + "|" + normalize(tag.Content)
+}
+
+func (tag Tag) label() string {
+	if tag.Classification != "" {
+		return tag.Classification + ": " + tag.Content
+	}
+	return tag.Content
+}
+
+// firstNonEmpty returns the first of values that isn't empty.
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// String returns the name as it would be displayed, e.g. "Clark Joseph Kent".
+func (name *Name) String() string {
+	return name.label()
+}
+
+// String returns the email address, or its MD5 hash when the address is hidden.
+func (email *Email) String() string {
+	return email.label()
+}
+
+// String returns the username.
+func (username *Username) String() string {
+	return username.label()
+}
+
+// String returns the user ID, e.g. "11231@facebook".
+func (userID *UserID) String() string {
+	return userID.label()
+}
+
+// String returns the phone number in international format when known.
+func (phone *Phone) String() string {
+	return phone.label()
+}
+
+// String returns the gender.
+func (gender *Gender) String() string {
+	return gender.label()
+}
+
+// String returns the date of birth as displayed by Pipl (usually an age), or
+// its date range.
+func (dob *DateOfBirth) String() string {
+	return dob.label()
+}
+
+// String returns the range as "start - end", or a single date.
+func (dateRange *DateRange) String() string {
+	return dateRange.label()
+}
+
+// String returns the language, e.g. "en_US".
+func (language *Language) String() string {
+	return language.label()
+}
+
+// String returns the ethnicity.
+func (ethnicity *Ethnicity) String() string {
+	return ethnicity.label()
+}
+
+// String returns the country code.
+func (originCountry *OriginCountry) String() string {
+	return originCountry.label()
+}
+
+// String returns the address on one line, e.g. "10 Hickory Lane, Smallville, KS".
+func (address *Address) String() string {
+	return address.label()
+}
+
+// String returns the job, e.g. "Field Reporter at Daily Planet".
+func (job *Job) String() string {
+	return job.label()
+}
+
+// String returns the education, e.g. "B.Sc Journalism from Metropolis University".
+func (education *Education) String() string {
+	return education.label()
+}
+
+// String returns the image URL.
+func (image *Image) String() string {
+	return image.label()
+}
+
+// String returns the URL.
+func (url *URL) String() string {
+	return url.label()
+}
+
+// String returns the relative's name, or failing that another identifier.
+func (relationship *Relationship) String() string {
+	return relationship.label()
+}
+
+// String returns the tag as "classification: content".
+func (tag *Tag) String() string {
+	return tag.label()
+}
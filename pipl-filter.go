@@ -0,0 +1,103 @@
+package pipl
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// ValidityPolicy decides which values of a profile to keep based on their
+// validity. The zero value keeps everything.
+type ValidityPolicy struct {
+	// CurrentOnly keeps only values marked as current
+	CurrentOnly bool
+
+	// ExcludeInferred drops values that Pipl inferred rather than observed
+	ExcludeInferred bool
+
+	// LastSeenAfter, if set, keeps only values last seen after this time.
+	// Values without a last_seen date are dropped.
+	LastSeenAfter time.Time
+
+	// ValidSinceFrom, if set, keeps only values that became valid at or after
+	// this time. Values without a valid_since date are dropped.
+	ValidSinceFrom time.Time
+}
+
+// Allows reports whether a value with the given validity passes the policy.
+func (policy ValidityPolicy) Allows(validity Validity) bool {
+	if policy.CurrentOnly && !validity.Current {
+		return false
+	}
+	if policy.ExcludeInferred && validity.Inferred {
+		return false
+	}
+	if !policy.LastSeenAfter.IsZero() && !validity.LastSeen.After(policy.LastSeenAfter) {
+		return false
+	}
+	if !policy.ValidSinceFrom.IsZero() {
+		validSince := validity.ValidSince.Time()
+		if validSince.IsZero() || validSince.Before(policy.ValidSinceFrom) {
+			return false
+		}
+	}
+	return true
+}
+
+// FilterPolicy applies a ValidityPolicy to a whole profile. Default applies to
+// every field category without an entry in Categories. For example, to keep only
+// current addresses but every job, past and present:
+//
+//	pipl.FilterPolicy{
+//		Default:    pipl.ValidityPolicy{ExcludeInferred: true},
+//		Categories: map[pipl.FieldCategory]pipl.ValidityPolicy{
+//			pipl.CategoryAddresses: {CurrentOnly: true},
+//			pipl.CategoryJobs:      {},
+//		},
+//	}
+type FilterPolicy struct {
+	Default    ValidityPolicy
+	Categories map[FieldCategory]ValidityPolicy
+}
+
+// For returns the policy that applies to a field category.
+func (policy FilterPolicy) For(category FieldCategory) ValidityPolicy {
+	if categoryPolicy, ok := policy.Categories[category]; ok {
+		return categoryPolicy
+	}
+	return policy.Default
+}
+
+// Filter returns a copy of the person holding only the values allowed by the
+// policy. Values without validity information (tags) are always kept. The
+// fields of relatives are filtered with the same policy. The person itself is
+// left untouched, and the copy shares no data with it.
+func (person *Person) Filter(policy FilterPolicy) *Person {
+	filtered := *person
+	filtered.PersonFields = person.PersonFields.clone()
+	filtered.PersonFields.filter(policy)
+	if person.Extra != nil {
+		filtered.Extra = make(map[string]json.RawMessage, len(person.Extra))
+		for key, value := range person.Extra {
+			filtered.Extra[key] = value
+		}
+	}
+	return &filtered
+}
+
+// filter drops the values that don't pass the policy, in place.
+func (fields *PersonFields) filter(policy FilterPolicy) {
+	for _, category := range FieldCategories {
+		categoryPolicy := policy.For(category)
+		var kept []fieldItem
+		for _, item := range fields.items(category) {
+			if validity := validityOf(item); validity != nil && !categoryPolicy.Allows(*validity) {
+				continue
+			}
+			if relationship, ok := item.(*Relationship); ok {
+				relationship.PersonFields.filter(policy)
+			}
+			kept = append(kept, item)
+		}
+		fields.setItems(category, kept)
+	}
+}
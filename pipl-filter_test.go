@@ -0,0 +1,48 @@
+package pipl_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/xpcmdshell/pipl"
+)
+
+func TestFilter(t *testing.T) {
+	response, _ := loadResponse(t, "person_response.json")
+	person := &response.Person
+
+	current := person.Filter(pipl.FilterPolicy{Default: pipl.ValidityPolicy{CurrentOnly: true}})
+	if len(current.Addresses) != 1 || len(current.Phones) != 1 || len(current.Emails) != 1 || len(current.Names) != 0 {
+		t.Errorf("unexpected current-only profile: %+v", current.PersonFields)
+	}
+	if len(person.Addresses) != 2 {
+		t.Error("filtering should not modify the original person")
+	}
+
+	mixed := person.Filter(pipl.FilterPolicy{
+		Default: pipl.ValidityPolicy{ExcludeInferred: true},
+		Categories: map[pipl.FieldCategory]pipl.ValidityPolicy{
+			pipl.CategoryAddresses: {CurrentOnly: true},
+		},
+	})
+	if len(mixed.Addresses) != 1 || len(mixed.Jobs) != 1 || len(mixed.Names) != 2 {
+		t.Errorf("unexpected per-category result: %+v", mixed.PersonFields)
+	}
+
+	recent := person.Filter(pipl.FilterPolicy{Default: pipl.ValidityPolicy{LastSeenAfter: time.Date(2016, 1, 1, 0, 0, 0, 0, time.UTC)}})
+	if len(recent.Emails) != 1 || len(recent.Addresses) != 1 || len(recent.Jobs) != 0 {
+		t.Errorf("unexpected last-seen result: %+v", recent.PersonFields)
+	}
+
+	since := person.Filter(pipl.FilterPolicy{Default: pipl.ValidityPolicy{ValidSinceFrom: time.Date(2010, 1, 1, 0, 0, 0, 0, time.UTC)}})
+	if len(since.Addresses) != 1 || len(since.Emails) != 1 || len(since.Usernames) != 1 {
+		t.Errorf("unexpected valid-since result: %+v", since.PersonFields)
+	}
+}
+
+func TestValidityPolicyInferred(t *testing.T) {
+	policy := pipl.ValidityPolicy{ExcludeInferred: true}
+	if policy.Allows(pipl.Validity{Inferred: true}) || !policy.Allows(pipl.Validity{}) {
+		t.Error("unexpected inferred handling")
+	}
+}
@@ -0,0 +1,222 @@
+package pipl
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// NewGraph builds a relationship graph out of persons and their relationships,
+// without running any searches. Relatives of relatives are included, and people
+// appearing several times are deduplicated as in GraphExpander. Persons that
+// turn out to be the same individual share a node holding the merged data of
+// both (see MergePersons); the persons themselves aren't modified. Use
+// GraphExpander instead to resolve relatives through follow up searches.
+func NewGraph(persons ...*Person) *Graph {
+	graph := new(Graph)
+	for _, person := range persons {
+		if node := graph.lookup(person); node != nil {
+			node.Person, _ = MergePersons(node.Person, person)
+			node.Depth, node.Searched = 0, true
+		}
+		graph.addRelatives(graph.add(person, 0, true), person, 1)
+	}
+	return graph
+}
+
+// addRelatives adds the relationships of person to the graph as relatives of
+// node, recursively.
+func (graph *Graph) addRelatives(node *GraphNode, person *Person, depth int) {
+	for i := range person.Relationships {
+		relationship := &person.Relationships[i]
+		relative := graph.lookup(relationship.ToPerson())
+		if relative == nil {
+			relative = graph.add(relationship.ToPerson(), depth, false)
+			graph.addRelatives(relative, relative.Person, depth+1)
+		}
+		graph.link(node.ID, relative.ID, relationship)
+	}
+}
+
+// nodeAttributes returns the data exported for each node.
+func nodeAttributes(node *GraphNode) (name string, emails []string, phones []string) {
+	for _, item := range node.Person.PersonFields.items(CategoryNames) {
+		if name = item.label(); name != "" {
+			break
+		}
+	}
+	for _, item := range node.Person.PersonFields.items(CategoryEmails) {
+		if label := item.label(); label != "" {
+			emails = append(emails, label)
+		}
+	}
+	for _, item := range node.Person.PersonFields.items(CategoryPhones) {
+		if label := item.label(); label != "" {
+			phones = append(phones, label)
+		}
+	}
+	return name, emails, phones
+}
+
+// edgeLabel renders the type and subtype of an edge, e.g. "family/Father".
+func edgeLabel(edge GraphEdge) string {
+	return joinNonEmpty("/", string(edge.Type), string(edge.Subtype))
+}
+
+// graphMLKeys declares the attributes written by WriteGraphML.
+var graphMLKeys = []struct{ id, target, attrType string }{
+	{"name", "node", "string"},
+	{"emails", "node", "string"},
+	{"phones", "node", "string"},
+	{"pipl_id", "node", "string"},
+	{"searched", "node", "boolean"},
+	{"depth", "node", "int"},
+	{"type", "edge", "string"},
+	{"subtype", "edge", "string"},
+}
+
+// WriteGraphML writes the graph in GraphML, the format read by Gephi, yEd and
+// most graph tools. Emails and phones are joined with "; ".
+func (graph *Graph) WriteGraphML(w io.Writer) error {
+	out := bufio.NewWriter(w)
+	out.WriteString(xml.Header)
+	out.WriteString(`<graphml xmlns="http://graphml.graphdrawing.org/xmlns">` + "\n")
+	for _, key := range graphMLKeys {
+		fmt.Fprintf(out, "  <key id=%q for=%q attr.name=%q attr.type=%q/>\n", key.id, key.target, key.id, key.attrType)
+	}
+	out.WriteString(`  <graph id="pipl" edgedefault="directed">` + "\n")
+	for _, node := range graph.Nodes {
+		name, emails, phones := nodeAttributes(node)
+		fmt.Fprintf(out, "    <node id=\"%s\">\n", xmlEscape(node.ID))
+		writeGraphMLData(out, "name", name)
+		writeGraphMLData(out, "emails", strings.Join(emails, "; "))
+		writeGraphMLData(out, "phones", strings.Join(phones, "; "))
+		writeGraphMLData(out, "pipl_id", string(node.Person.ID))
+		writeGraphMLData(out, "searched", fmt.Sprint(node.Searched))
+		writeGraphMLData(out, "depth", fmt.Sprint(node.Depth))
+		out.WriteString("    </node>\n")
+	}
+	for _, edge := range graph.Edges {
+		fmt.Fprintf(out, "    <edge source=\"%s\" target=\"%s\">\n", xmlEscape(edge.From), xmlEscape(edge.To))
+		writeGraphMLData(out, "type", string(edge.Type))
+		writeGraphMLData(out, "subtype", string(edge.Subtype))
+		out.WriteString("    </edge>\n")
+	}
+	out.WriteString("  </graph>\n</graphml>\n")
+	return out.Flush()
+}
+
+func writeGraphMLData(out *bufio.Writer, key string, value string) {
+	if value != "" {
+		fmt.Fprintf(out, "      <data key=%q>%s</data>\n", key, xmlEscape(value))
+	}
+}
+
+func xmlEscape(value string) string {
+	var builder strings.Builder
+	xml.EscapeText(&builder, []byte(value))
+	return builder.String()
+}
+
+// WriteDOT writes the graph in the Graphviz DOT language. Nodes are labelled
+// with the person's name, emails and phones, and edges with the relationship
+// type and subtype.
+func (graph *Graph) WriteDOT(w io.Writer) error {
+	out := bufio.NewWriter(w)
+	out.WriteString("digraph pipl {\n")
+	for _, node := range graph.Nodes {
+		name, emails, phones := nodeAttributes(node)
+		lines := append([]string{firstNonEmpty(name, node.ID)}, emails...)
+		lines = append(lines, phones...)
+		style := ""
+		if !node.Searched {
+			style = ", style=dashed"
+		}
+		fmt.Fprintf(out, "  %s [label=%s%s];\n", dotQuote(node.ID), dotQuote(strings.Join(lines, "\n")), style)
+	}
+	for _, edge := range graph.Edges {
+		fmt.Fprintf(out, "  %s -> %s", dotQuote(edge.From), dotQuote(edge.To))
+		if label := edgeLabel(edge); label != "" {
+			fmt.Fprintf(out, " [label=%s]", dotQuote(label))
+		}
+		out.WriteString(";\n")
+	}
+	out.WriteString("}\n")
+	return out.Flush()
+}
+
+// dotQuote quotes a DOT identifier, escaping quotes and turning newlines into
+// centred line breaks.
+func dotQuote(value string) string {
+	value = strings.Replace(value, `\`, `\\`, -1)
+	value = strings.Replace(value, `"`, `\"`, -1)
+	value = strings.Replace(value, "\n", `\n`, -1)
+	return `"` + value + `"`
+}
+
+// WriteCypher writes the graph as Neo4j Cypher statements, one per line. People
+// are MERGEd as :Person nodes keyed on their node ID. People identified by a
+// Pipl ID, email, phone, username or user ID keep that ID, so overlapping
+// exports share their nodes. Anyone else (relatives known only by name) gets an
+// ID scoped to this graph, derived from its content: running the same
+// statements again doesn't create duplicates, but two exports never merge
+// their unidentified people. Relationships are MERGEd with the upper-cased
+// relationship type as their label (e.g. :FAMILY), or :RELATED_TO if the type
+// is unknown, and carry the subtype as a property.
+func (graph *Graph) WriteCypher(w io.Writer) error {
+	out := bufio.NewWriter(w)
+	ids := graph.cypherIDs()
+	for _, node := range graph.Nodes {
+		name, emails, phones := nodeAttributes(node)
+		fmt.Fprintf(out, "MERGE (p:Person {id: %s}) SET p.name = %s, p.emails = %s, p.phones = %s, p.pipl_id = %s, p.searched = %t;\n",
+			cypherQuote(ids[node.ID]), cypherQuote(name), cypherList(emails), cypherList(phones), cypherQuote(string(node.Person.ID)), node.Searched)
+	}
+	for _, edge := range graph.Edges {
+		label := "RELATED_TO"
+		if edge.Type.IsKnown() {
+			label = strings.ToUpper(string(edge.Type))
+		}
+		fmt.Fprintf(out, "MATCH (a:Person {id: %s}), (b:Person {id: %s}) MERGE (a)-[r:%s]->(b) SET r.type = %s, r.subtype = %s;\n",
+			cypherQuote(ids[edge.From]), cypherQuote(ids[edge.To]), label, cypherQuote(string(edge.Type)), cypherQuote(string(edge.Subtype)))
+	}
+	return out.Flush()
+}
+
+// cypherIDs maps node IDs to the IDs written by WriteCypher. Node IDs built from
+// names or numbered by add are only unique within the graph, so they're
+// prefixed with a hash of the graph.
+func (graph *Graph) cypherIDs() map[string]string {
+	data, _ := json.Marshal(graph)
+	sum := sha1.Sum(data)
+	scope := "graph-" + hex.EncodeToString(sum[:8]) + "/"
+	ids := make(map[string]string, len(graph.Nodes))
+	for _, node := range graph.Nodes {
+		ids[node.ID] = node.ID
+		if strings.HasPrefix(node.ID, string(CategoryNames)+":") || strings.HasPrefix(node.ID, "node-") {
+			ids[node.ID] = scope + node.ID
+		}
+	}
+	return ids
+}
+
+// cypherQuote renders a Cypher string literal.
+func cypherQuote(value string) string {
+	value = strings.Replace(value, `\`, `\\`, -1)
+	value = strings.Replace(value, `'`, `\'`, -1)
+	value = strings.Replace(value, "\n", `\n`, -1)
+	return "'" + value + "'"
+}
+
+// cypherList renders a Cypher list of strings.
+func cypherList(values []string) string {
+	quoted := make([]string, 0, len(values))
+	for _, value := range values {
+		quoted = append(quoted, cypherQuote(value))
+	}
+	return "[" + strings.Join(quoted, ", ") + "]"
+}
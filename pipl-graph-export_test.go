@@ -0,0 +1,111 @@
+package pipl_test
+
+import (
+	"bytes"
+	"encoding/xml"
+	"strings"
+	"testing"
+
+	"github.com/xpcmdshell/pipl"
+)
+
+func TestGraphExport(t *testing.T) {
+	response, _ := loadResponse(t, "person_response.json")
+	graph := pipl.NewGraph(&response.Person)
+	if len(graph.Nodes) != 3 || len(graph.Edges) != 2 {
+		t.Fatalf("unexpected graph: %d nodes, %d edges", len(graph.Nodes), len(graph.Edges))
+	}
+
+	var graphML bytes.Buffer
+	if err := graph.WriteGraphML(&graphML); err != nil {
+		t.Fatal(err)
+	}
+	var parsed struct {
+		Nodes []struct {
+			ID string `xml:"id,attr"`
+		} `xml:"graph>node"`
+		Edges []struct {
+			Source string `xml:"source,attr"`
+		} `xml:"graph>edge"`
+	}
+	if err := xml.Unmarshal(graphML.Bytes(), &parsed); err != nil {
+		t.Fatalf("invalid GraphML: %v\n%s", err, graphML.String())
+	}
+	if len(parsed.Nodes) != 3 || len(parsed.Edges) != 2 || !strings.Contains(graphML.String(), `<data key="subtype">Father</data>`) {
+		t.Errorf("unexpected GraphML:\n%s", graphML.String())
+	}
+
+	var dot bytes.Buffer
+	if err := graph.WriteDOT(&dot); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(dot.String(), `"a7a2a3a1-1b57-4b43-b4c6-23b79a1e3ab4" -> "emails:lois@dailyplanet.example.com" [label="friend"];`) {
+		t.Errorf("unexpected DOT:\n%s", dot.String())
+	}
+
+	var cypher bytes.Buffer
+	if err := graph.WriteCypher(&cypher); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(cypher.String(), "MERGE (a)-[r:FAMILY]->(b) SET r.type = 'family', r.subtype = 'Father';") ||
+		!strings.Contains(cypher.String(), "p.name = 'Lois Lane', p.emails = ['lois@dailyplanet.example.com']") {
+		t.Errorf("unexpected Cypher:\n%s", cypher.String())
+	}
+
+	relative := pipl.Relationship{Type: pipl.RelationshipTypeFriend}
+	relative.Names = []pipl.Name{{Display: `Bruce "Bats" Wayne`}}
+	other := &pipl.Person{ID: "x'y"}
+	other.AddRelationship(relative)
+	cypher.Reset()
+	pipl.NewGraph(other).WriteCypher(&cypher)
+	if !strings.Contains(cypher.String(), `{id: 'x\'y'}`) {
+		t.Errorf("Cypher strings not escaped:\n%s", cypher.String())
+	}
+}
+
+func TestNewGraphMergesDuplicates(t *testing.T) {
+	first := pipl.NewPerson()
+	first.AddEmail("clark@example.com")
+	first.AddPhone(9785550145)
+	first.AddRelationship(relative(pipl.RelationshipTypeFriend, "", "Lois", "Lane", "lois@example.com"))
+	second := pipl.NewPerson()
+	second.AddEmail("clark@example.com")
+	second.AddPhone(6175550123)
+	second.AddRelationship(relative(pipl.RelationshipTypeWork, "", "Jimmy", "Olsen", "jimmy@example.com"))
+
+	graph := pipl.NewGraph(first, second)
+	if len(graph.Nodes) != 3 || len(graph.Edges) != 2 {
+		t.Fatalf("unexpected graph: %d nodes, %d edges: %+v", len(graph.Nodes), len(graph.Edges), graph.Edges)
+	}
+	if phones := graph.Nodes[0].Person.Phones; len(phones) != 2 {
+		t.Errorf("expected the phones of both records, got %+v", phones)
+	}
+	if len(first.Phones) != 1 {
+		t.Error("NewGraph modified its input")
+	}
+}
+
+func TestWriteCypherScopesWeakIDs(t *testing.T) {
+	export := func(id pipl.GUID) string {
+		person := &pipl.Person{ID: id}
+		person.AddRelationship(relative(pipl.RelationshipTypeFamily, "", "John", "Smith", ""))
+		person.AddRelationship(relative(pipl.RelationshipTypeFriend, "", "Lois", "Lane", "lois@example.com"))
+		var cypher bytes.Buffer
+		if err := pipl.NewGraph(person).WriteCypher(&cypher); err != nil {
+			t.Fatal(err)
+		}
+		return cypher.String()
+	}
+	first, second := export("clark"), export("bruce")
+	if first != export("clark") {
+		t.Error("exporting the same graph twice should give the same statements")
+	}
+	if !strings.Contains(first, "{id: 'emails:lois@example.com'}") || !strings.Contains(second, "{id: 'emails:lois@example.com'}") {
+		t.Errorf("people with an email should keep it as their ID:\n%s", first)
+	}
+	for _, line := range strings.Split(first, "\n") {
+		if strings.Contains(line, "John Smith") && (strings.Contains(second, line) || strings.Contains(line, "{id: 'names:")) {
+			t.Errorf("people known only by name should be scoped to their graph:\n%s\n%s", first, second)
+		}
+	}
+}
@@ -0,0 +1,311 @@
+package pipl
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// Searcher runs Pipl searches. *Client implements it; tools built on top of
+// the client (such as GraphExpander) accept a Searcher so they can be tested
+// without hitting the API. When the Pipl service answers with an error, both
+// methods return an *ErrAPI, SearchByPerson along with the response.
+// Implementations that return the response alone are tolerated: callers also
+// check Response.Err.
+type Searcher interface {
+	SearchByPerson(searchObject *Person) (*Response, error)
+	SearchByPointer(searchPointer string) (*Person, error)
+}
+
+// GraphNode is a person in a relationship graph. ID is the Pipl person ID if
+// the person was resolved by a search, or a fingerprint built from the person's
+// data otherwise. Searched reports whether a search resolved the node to a Pipl
+// profile; relatives that couldn't be searched (not enough data, budget spent,
+// no match) only hold what their relationship entry contained.
+type GraphNode struct {
+	ID       string  `json:"id"`
+	Person   *Person `json:"person"`
+	Depth    int     `json:"depth"`
+	Searched bool    `json:"searched"`
+	Error    string  `json:"error,omitempty"`
+}
+
+// GraphEdge links a person to one of their relatives, labelled with the type
+// and subtype of the relationship (e.g. "family", "Father").
+type GraphEdge struct {
+	From    string              `json:"from"`
+	To      string              `json:"to"`
+	Type    RelationshipType    `json:"type,omitempty"`
+	Subtype RelationshipSubtype `json:"subtype,omitempty"`
+}
+
+// Graph is a network of people linked by their relationships.
+type Graph struct {
+	Nodes []*GraphNode `json:"nodes"`
+	Edges []GraphEdge  `json:"edges"`
+
+	// index maps node IDs and identifying keys (emails, phones...) to nodes
+	index map[string]*GraphNode
+}
+
+// Node returns the node with the given ID, or nil.
+func (graph *Graph) Node(id string) *GraphNode {
+	for _, node := range graph.Nodes {
+		if node.ID == id {
+			return node
+		}
+	}
+	return nil
+}
+
+// lookup finds an existing node holding the same person, by ID or by any of the
+// person's identifying keys.
+func (graph *Graph) lookup(person *Person) *GraphNode {
+	for _, key := range identityKeys(person) {
+		if node, ok := graph.index[key]; ok {
+			return node
+		}
+	}
+	return nil
+}
+
+// add adds a node for person, or returns the existing node if the person is
+// already in the graph. Existing nodes gain the data of the new person.
+func (graph *Graph) add(person *Person, depth int, searched bool) *GraphNode {
+	if graph.index == nil {
+		graph.index = make(map[string]*GraphNode)
+	}
+	node := graph.lookup(person)
+	if node == nil {
+		node = &GraphNode{ID: nodeID(person), Person: person, Depth: depth, Searched: searched}
+		if node.ID == "" {
+			node.ID = fmt.Sprintf("node-%d", len(graph.Nodes))
+		}
+		graph.Nodes = append(graph.Nodes, node)
+	} else if searched && !node.Searched {
+		node.Person.Merge(person)
+		node.Searched = true
+	}
+	for _, key := range identityKeys(person) {
+		if _, ok := graph.index[key]; !ok {
+			graph.index[key] = node
+		}
+	}
+	return node
+}
+
+// link adds an edge, unless the same edge already exists.
+func (graph *Graph) link(from string, to string, relationship *Relationship) {
+	edge := GraphEdge{From: from, To: to, Type: relationship.Type, Subtype: relationship.Subtype}
+	if from == to {
+		return
+	}
+	for _, existing := range graph.Edges {
+		if existing == edge {
+			return
+		}
+	}
+	graph.Edges = append(graph.Edges, edge)
+}
+
+// identityKeys returns the keys under which a person is indexed: the Pipl ID
+// and every email, phone, username and user ID. Names are only used for people
+// with none of those, since names alone are a weak identifier.
+func identityKeys(person *Person) []string {
+	var keys []string
+	if person.ID != "" {
+		keys = append(keys, "id:"+string(person.ID))
+	}
+	for _, category := range []FieldCategory{CategoryEmails, CategoryPhones, CategoryUsernames, CategoryUserIDs} {
+		for _, item := range person.PersonFields.items(category) {
+			if key := item.key(); key != "" {
+				keys = append(keys, string(category)+":"+key)
+			}
+		}
+	}
+	if len(keys) == 0 {
+		for _, name := range person.Names {
+			if key := name.key(); key != "" {
+				keys = append(keys, "names:"+key)
+			}
+		}
+	}
+	return keys
+}
+
+// nodeID returns the ID of a node for person: its Pipl ID if known, otherwise a
+// fingerprint of its most distinctive data. It returns "" for persons with
+// neither, which add numbers instead.
+func nodeID(person *Person) string {
+	if person.ID != "" {
+		return string(person.ID)
+	}
+	if keys := identityKeys(person); len(keys) > 0 {
+		return keys[0]
+	}
+	return ""
+}
+
+// GraphExpander builds a relationship graph around a person by searching for
+// their relatives, then the relatives' relatives, and so on.
+type GraphExpander struct {
+	// Searcher runs the searches, typically a *Client
+	Searcher Searcher
+
+	// MaxDepth is the number of hops to search away from the root person. With
+	// a depth of 1, only the root's relatives are searched.
+	MaxDepth int
+
+	// MaxSearches caps the number of searches (including pointer lookups) an
+	// expansion may run, since every search is billed. Zero means no limit.
+	// Relatives left over once the budget is spent are added unresolved.
+	MaxSearches int
+
+	// Concurrency is the number of searches run at once (at least 1)
+	Concurrency int
+
+	// MinMatch is the match confidence a possible person needs for an ambiguous
+	// search to be resolved through its search pointer. Zero leaves ambiguous
+	// relatives unresolved.
+	MinMatch float32
+}
+
+// expansionJob is a relative waiting to be searched.
+type expansionJob struct {
+	parent       *GraphNode
+	relationship *Relationship
+	query        *Person
+	result       *Person
+	err          error
+}
+
+// Expand builds the graph around root, which should be a full profile (e.g.
+// Response.Person). Every relationship becomes an edge; relatives are searched
+// up to MaxDepth hops away and deduplicated by Pipl ID, email, phone, username
+// or user ID. If ctx is cancelled, Expand stops and returns the graph built so
+// far along with the context's error.
+func (expander *GraphExpander) Expand(ctx context.Context, root *Person) (*Graph, error) {
+	graph := new(Graph)
+	frontier := []*GraphNode{graph.add(root, 0, true)}
+	budget := expander.MaxSearches
+	var budgetLock sync.Mutex
+	spend := func() bool {
+		budgetLock.Lock()
+		defer budgetLock.Unlock()
+		if expander.MaxSearches == 0 {
+			return true
+		}
+		if budget == 0 {
+			return false
+		}
+		budget--
+		return true
+	}
+
+	for depth := 1; depth <= expander.MaxDepth && len(frontier) > 0; depth++ {
+		jobs := expander.plan(graph, frontier)
+		if err := expander.run(ctx, jobs, spend); err != nil {
+			return graph, err
+		}
+		frontier = nil
+		for _, job := range jobs {
+			var node *GraphNode
+			if job.result != nil {
+				node = graph.add(job.result, depth, true)
+			} else {
+				node = graph.add(job.relationship.ToPerson(), depth, false)
+				if job.err != nil && node.Error == "" {
+					node.Error = job.err.Error()
+				}
+			}
+			graph.link(job.parent.ID, node.ID, job.relationship)
+			if job.result != nil && node.Depth == depth {
+				frontier = append(frontier, node)
+			}
+		}
+	}
+	return graph, nil
+}
+
+// plan turns the relationships of the frontier into jobs. Relatives already in
+// the graph are linked straight away instead of being searched again.
+func (expander *GraphExpander) plan(graph *Graph, frontier []*GraphNode) []*expansionJob {
+	var jobs []*expansionJob
+	pending := make(map[string]bool)
+	for _, parent := range frontier {
+		for i := range parent.Person.Relationships {
+			relationship := &parent.Person.Relationships[i]
+			relative := relationship.ToPerson()
+			if existing := graph.lookup(relative); existing != nil {
+				graph.link(parent.ID, existing.ID, relationship)
+				continue
+			}
+			query, _ := relationship.SearchQuery()
+			job := &expansionJob{parent: parent, relationship: relationship, query: query}
+			for _, key := range identityKeys(relative) {
+				if pending[key] {
+					job.query = nil
+				}
+				pending[key] = true
+			}
+			jobs = append(jobs, job)
+		}
+	}
+	return jobs
+}
+
+// run searches every job with a query, at most Concurrency at a time.
+func (expander *GraphExpander) run(ctx context.Context, jobs []*expansionJob, spend func() bool) error {
+	concurrency := expander.Concurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	slots := make(chan struct{}, concurrency)
+	var wait sync.WaitGroup
+	for _, job := range jobs {
+		if job.query == nil {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			wait.Wait()
+			return err
+		}
+		slots <- struct{}{}
+		wait.Add(1)
+		go func(job *expansionJob) {
+			defer func() {
+				<-slots
+				wait.Done()
+			}()
+			job.result, job.err = expander.resolve(ctx, job.query, spend)
+		}(job)
+	}
+	wait.Wait()
+	return ctx.Err()
+}
+
+// resolve searches for a relative and returns their full profile, if one can
+// be found within the budget.
+func (expander *GraphExpander) resolve(ctx context.Context, query *Person, spend func() bool) (*Person, error) {
+	if ctx.Err() != nil || !spend() {
+		return nil, nil
+	}
+	response, err := expander.Searcher.SearchByPerson(query)
+	if err != nil {
+		return nil, err
+	}
+	if err := response.Err(); err != nil {
+		return nil, err
+	}
+	best, complete := response.Best()
+	if best == nil || complete {
+		return best, nil
+	}
+	if expander.MinMatch == 0 || best.Match < expander.MinMatch || best.SearchPointer == "" {
+		return nil, nil
+	}
+	if ctx.Err() != nil || !spend() {
+		return nil, nil
+	}
+	return expander.Searcher.SearchByPointer(best.SearchPointer)
+}
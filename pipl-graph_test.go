@@ -0,0 +1,140 @@
+package pipl_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/xpcmdshell/pipl"
+)
+
+// fakeSearcher answers searches from a fixed set of profiles, keyed by email.
+type fakeSearcher struct {
+	lock     sync.Mutex
+	profiles map[string]*pipl.Person
+	searches int
+}
+
+func (searcher *fakeSearcher) SearchByPerson(searchObject *pipl.Person) (*pipl.Response, error) {
+	searcher.lock.Lock()
+	defer searcher.lock.Unlock()
+	searcher.searches++
+	for _, email := range searchObject.Emails {
+		if profile, ok := searcher.profiles[email.Address]; ok {
+			return &pipl.Response{PersonsCount: 1, Person: *profile}, nil
+		}
+	}
+	return &pipl.Response{}, nil
+}
+
+func (searcher *fakeSearcher) SearchByPointer(searchPointer string) (*pipl.Person, error) {
+	return nil, nil
+}
+
+// relative builds a relationship holding a name and an email.
+func relative(relationshipType pipl.RelationshipType, subtype pipl.RelationshipSubtype, first string, last string, email string) pipl.Relationship {
+	relationship := pipl.Relationship{Type: relationshipType, Subtype: subtype}
+	relationship.Names = []pipl.Name{{First: first, Last: last}}
+	if email != "" {
+		relationship.Emails = []pipl.Email{{Address: email}}
+	}
+	return relationship
+}
+
+func TestGraphExpander(t *testing.T) {
+	root := &pipl.Person{ID: "clark"}
+	root.AddName("Clark", "", "Kent", "", "")
+	root.AddEmail("clark@example.com")
+	root.AddRelationship(relative(pipl.RelationshipTypeFriend, "", "Lois", "Lane", "lois@example.com"))
+	root.AddRelationship(relative(pipl.RelationshipTypeFamily, "Father", "Jonathan", "", ""))
+
+	lois := &pipl.Person{ID: "lois"}
+	lois.AddName("Lois", "", "Lane", "", "")
+	lois.AddEmail("lois@example.com")
+	lois.AddRelationship(relative(pipl.RelationshipTypeFriend, "", "Clark", "Kent", "clark@example.com"))
+	lois.AddRelationship(relative(pipl.RelationshipTypeWork, "", "Jimmy", "Olsen", "jimmy@example.com"))
+
+	jimmy := &pipl.Person{ID: "jimmy"}
+	jimmy.AddName("Jimmy", "", "Olsen", "", "")
+	jimmy.AddEmail("jimmy@example.com")
+
+	searcher := &fakeSearcher{profiles: map[string]*pipl.Person{"lois@example.com": lois, "jimmy@example.com": jimmy}}
+	expander := &pipl.GraphExpander{Searcher: searcher, MaxDepth: 2, Concurrency: 2}
+	graph, err := expander.Expand(context.Background(), root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(graph.Nodes) != 4 || len(graph.Edges) != 4 {
+		t.Fatalf("unexpected graph: %d nodes, %d edges: %+v", len(graph.Nodes), len(graph.Edges), graph.Edges)
+	}
+	if searcher.searches != 2 {
+		t.Errorf("expected 2 searches, got %d", searcher.searches)
+	}
+	if node := graph.Node("lois"); node == nil || !node.Searched || node.Depth != 1 {
+		t.Errorf("unexpected node for Lois: %+v", node)
+	}
+	if node := graph.Node("names:jonathan"); node == nil || node.Searched {
+		t.Errorf("expected an unresolved node for Jonathan: %+v", node)
+	}
+	found := false
+	for _, edge := range graph.Edges {
+		if edge.From == "lois" && edge.To == "clark" {
+			found = true
+		}
+	}
+	if !found {
+		t.Error("expected Lois to link back to the existing node for Clark")
+	}
+
+	searcher.searches = 0
+	expander.MaxSearches = 1
+	graph, err = expander.Expand(context.Background(), root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if searcher.searches != 1 || len(graph.Nodes) != 4 {
+		t.Errorf("budget not respected: %d searches, %d nodes", searcher.searches, len(graph.Nodes))
+	}
+}
+
+func TestGraphExpanderAnonymousRelatives(t *testing.T) {
+	root := &pipl.Person{ID: "clark"}
+	root.AddEmail("clark@example.com")
+	for _, city := range []string{"Smallville", "Metropolis"} {
+		relationship := pipl.Relationship{Type: pipl.RelationshipTypeFamily}
+		relationship.Addresses = []pipl.Address{{City: city}}
+		root.AddRelationship(relationship)
+	}
+	expander := &pipl.GraphExpander{Searcher: &fakeSearcher{}, MaxDepth: 1}
+	graph, err := expander.Expand(context.Background(), root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(graph.Nodes) != 3 || len(graph.Edges) != 2 || graph.Edges[0].To == graph.Edges[1].To {
+		t.Errorf("expected relatives without identifiers to stay apart: %+v", graph.Edges)
+	}
+}
+
+// errorSearcher answers every search with an API error.
+type errorSearcher struct{}
+
+func (errorSearcher) SearchByPerson(searchObject *pipl.Person) (*pipl.Response, error) {
+	return &pipl.Response{HTTPStatusCode: 403, Error: "quota"}, nil
+}
+
+func (errorSearcher) SearchByPointer(searchPointer string) (*pipl.Person, error) {
+	return nil, nil
+}
+
+func TestGraphExpanderAPIError(t *testing.T) {
+	root := &pipl.Person{ID: "clark"}
+	root.AddRelationship(relative(pipl.RelationshipTypeFriend, "", "Lois", "Lane", "lois@example.com"))
+	expander := &pipl.GraphExpander{Searcher: errorSearcher{}, MaxDepth: 1}
+	graph, err := expander.Expand(context.Background(), root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if node := graph.Node("emails:lois@example.com"); node == nil || node.Searched || node.Error != "quota" {
+		t.Errorf("expected the API error on Lois's node: %+v", node)
+	}
+}
@@ -1,5 +1,7 @@
 package pipl
 
+import "fmt"
+
 // ErrInsufficientSearch is an error type that may be returned by
 // SearchByPerson which denotes that the search object provided does not meet
 // the minimum requirements.
@@ -9,6 +11,54 @@ func (err *ErrInsufficientSearch) Error() string {
 	return "The search object submitted does not contain sufficient terms. Must have a complete entry for one of the following: Name, email, phone, username, userID, url"
 }
 
+// ErrInvalidValue is an error type returned by the Add*/Set* helpers and
+// SearchByPerson when a typed field (gender, name/address/phone/email type or
+// relationship type) holds a value that isn't documented by Pipl.
+type ErrInvalidValue struct {
+	Field string
+	Value string
+}
+
+func (err *ErrInvalidValue) Error() string {
+	return fmt.Sprintf("%q is not a valid %s", err.Value, err.Field)
+}
+
+// ErrHTTPStatus is an error type returned when a Pipl service answers with an
+// unsuccessful HTTP status and no response that can be decoded.
+type ErrHTTPStatus struct {
+	StatusCode int
+	Status     string
+	Body       []byte
+}
+
+func (err *ErrHTTPStatus) Error() string {
+	return fmt.Sprintf("The Pipl service returned an unexpected status: %s", err.Status)
+}
+
+// ErrAPI is an error type for responses in which the Pipl service reports an
+// error, e.g. an invalid key or an exceeded quota. The client returns those as
+// a Response, see Response.Err.
+type ErrAPI struct {
+	StatusCode int
+	Message    string
+}
+
+func (err *ErrAPI) Error() string {
+	if err.Message == "" {
+		return fmt.Sprintf("The Pipl service returned an error status: %d", err.StatusCode)
+	}
+	return err.Message
+}
+
+// newDateRange builds a DateRange for the helpers below, or nil if neither end
+// of the range is known (so it's omitted from the search object entirely).
+func newDateRange(start string, end string) *DateRange {
+	if start == "" && end == "" {
+		return nil
+	}
+	return &DateRange{Start: Date(start), End: Date(end)}
+}
+
 // NewPerson makes a new blank person object to be filled with terms
 func NewPerson() *Person {
 	return new(Person)
@@ -25,6 +75,23 @@ func (searchObject *Person) AddName(firstName string, middleName string, lastNam
 	searchObject.Names = append(searchObject.Names, *newName)
 }
 
+// AddNameWithType adds a name of the given type (e.g. NameTypeMaiden) to the
+// search object. Omit unused fields.
+func (searchObject *Person) AddNameWithType(firstName string, middleName string, lastName string, prefix string, suffix string, nameType NameType) error {
+	if err := checkEnum("name type", string(nameType), nameType.IsKnown()); err != nil {
+		return err
+	}
+	newName := new(Name)
+	newName.First = firstName
+	newName.Middle = middleName
+	newName.Last = lastName
+	newName.Prefix = prefix
+	newName.Suffix = suffix
+	newName.Type = nameType
+	searchObject.Names = append(searchObject.Names, *newName)
+	return nil
+}
+
 // AddNameRaw can be used when you're unsure how to handle breaking down the name in
 // question into its constituent parts. Basically, let Pipl handle parsing it.
 func (searchObject *Person) AddNameRaw(fullName string) {
@@ -40,6 +107,19 @@ func (searchObject *Person) AddEmail(emailAddress string) {
 	searchObject.Emails = append(searchObject.Emails, *newEmail)
 }
 
+// AddEmailWithType appends an email address of the given type (e.g. EmailTypeWork)
+// to the specified search object
+func (searchObject *Person) AddEmailWithType(emailAddress string, emailType EmailType) error {
+	if err := checkEnum("email type", string(emailType), emailType.IsKnown()); err != nil {
+		return err
+	}
+	newEmail := new(Email)
+	newEmail.Address = emailAddress
+	newEmail.Type = emailType
+	searchObject.Emails = append(searchObject.Emails, *newEmail)
+	return nil
+}
+
 // AddUsername appends a username to the specified search object
 func (searchObject *Person) AddUsername(username string) {
 	newUsername := new(Username)
@@ -54,19 +134,38 @@ func (searchObject *Person) AddPhone(phoneNumber int) {
 	searchObject.Phones = append(searchObject.Phones, *newPhone)
 }
 
-// SetGender sets the gender of the specified search object
-func (searchObject *Person) SetGender(gender string) {
+// AddPhoneWithType appends a phone of the given type (e.g. PhoneTypeMobile) to
+// the specified search object
+func (searchObject *Person) AddPhoneWithType(phoneNumber int, phoneType PhoneType) error {
+	if err := checkEnum("phone type", string(phoneType), phoneType.IsKnown()); err != nil {
+		return err
+	}
+	newPhone := new(Phone)
+	newPhone.Number = phoneNumber
+	newPhone.Type = phoneType
+	searchObject.Phones = append(searchObject.Phones, *newPhone)
+	return nil
+}
+
+// SetGender sets the gender of the specified search object. Gender must be
+// one of GenderMale or GenderFemale; other values are rejected with an
+// *ErrInvalidValue and leave the search object unchanged. (SetGender used to
+// return nothing and accept any value, so check the error.)
+func (searchObject *Person) SetGender(gender GenderValue) error {
+	if !gender.IsKnown() {
+		return &ErrInvalidValue{Field: "gender", Value: string(gender)}
+	}
 	newGender := new(Gender)
 	newGender.Content = gender
 	searchObject.Gender = newGender
+	return nil
 }
 
 // SetDateOfBirth sets the DOB of the specified search object
 // DOB string format: "YYYY-MM-DD"
 func (searchObject *Person) SetDateOfBirth(dob string) {
 	newDOB := new(DateOfBirth)
-	newDOB.DateRange.Start = dob
-	newDOB.DateRange.End = dob
+	newDOB.DateRange = newDateRange(dob, dob)
 	searchObject.DateOfBirth = newDOB
 }
 
@@ -107,6 +206,25 @@ func (searchObject *Person) AddAddress(house string, street string, apartment st
 	searchObject.Addresses = append(searchObject.Addresses, *newAddress)
 }
 
+// AddAddressWithType appends an address of the given type (e.g.
+// AddressTypeWork) to the specified search object
+func (searchObject *Person) AddAddressWithType(house string, street string, apartment string, city string, state string, country string, poBox string, addressType AddressType) error {
+	if err := checkEnum("address type", string(addressType), addressType.IsKnown()); err != nil {
+		return err
+	}
+	newAddress := new(Address)
+	newAddress.House = house
+	newAddress.Street = street
+	newAddress.Apartment = apartment
+	newAddress.City = city
+	newAddress.State = state
+	newAddress.Country = country
+	newAddress.POBox = poBox
+	newAddress.Type = addressType
+	searchObject.Addresses = append(searchObject.Addresses, *newAddress)
+	return nil
+}
+
 // AddAddressRaw can be used when many of the address parts are missing, or
 // you're unsure how to split it up. Let Pipl handle parsing.
 func (searchObject *Person) AddAddressRaw(fullAddress string) {
@@ -121,8 +239,7 @@ func (searchObject *Person) AddJob(title string, organization string, industry s
 	newJob.Title = title
 	newJob.Organization = organization
 	newJob.Industry = industry
-	newJob.DateRange.Start = dateRangeStart
-	newJob.DateRange.End = dateRangeEnd
+	newJob.DateRange = newDateRange(dateRangeStart, dateRangeEnd)
 	searchObject.Jobs = append(searchObject.Jobs, *newJob)
 }
 
@@ -131,8 +248,7 @@ func (searchObject *Person) AddEducation(degree string, school string, dateRange
 	newEducation := new(Education)
 	newEducation.Degree = degree
 	newEducation.School = school
-	newEducation.DateRange.Start = dateRangeStart
-	newEducation.DateRange.End = dateRangeEnd
+	newEducation.DateRange = newDateRange(dateRangeStart, dateRangeEnd)
 	searchObject.Educations = append(searchObject.Educations, *newEducation)
 }
 
@@ -150,7 +266,14 @@ func (searchObject *Person) AddURL(url string) {
 	searchObject.URLs = append(searchObject.URLs, *newURL)
 }
 
-// AddRelationship appends a relationship entry to the specified search object
-func (searchObject *Person) AddRelationship(relationship Relationship) {
+// AddRelationship appends a relationship entry to the specified search object.
+// The relationship type, if set, must be one of the RelationshipType constants;
+// other values are rejected with an *ErrInvalidValue and the relationship isn't
+// added. (AddRelationship used to return nothing, so check the error.)
+func (searchObject *Person) AddRelationship(relationship Relationship) error {
+	if err := checkEnum("relationship type", string(relationship.Type), relationship.Type.IsKnown()); err != nil {
+		return err
+	}
 	searchObject.Relationships = append(searchObject.Relationships, relationship)
+	return nil
 }
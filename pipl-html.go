@@ -0,0 +1,185 @@
+package pipl
+
+import (
+	"html/template"
+	"io"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// HTMLReportOptions controls the HTML report.
+type HTMLReportOptions struct {
+	// Title heads the report. It defaults to "Pipl report".
+	Title string
+
+	// Parameters are the search parameters behind the response, listed in the
+	// footer so the search can be reproduced. The API key is never included.
+	// Pass the client's SearchParameters: the response doesn't record them.
+	Parameters *SearchParameters
+
+	// Timestamp is the time of the search. Without it, the footer shows the
+	// time the report was generated, labelled as such.
+	Timestamp time.Time
+}
+
+// htmlReport is the data of the HTML report template.
+type htmlReport struct {
+	Title      string
+	CSS        template.CSS
+	Query      string
+	Outcome    string
+	Header     []string
+	Rows       [][]string
+	Persons    []htmlPerson
+	Search     reportSection
+	Timestamp  reportLine
+	Parameters []reportLine
+}
+
+// htmlPerson is a person in the HTML report.
+type htmlPerson struct {
+	Title    string
+	Match    string
+	Open     bool
+	Images   []string
+	Sections []reportSection
+}
+
+// WriteHTMLReport writes a self-contained HTML report of the response, for
+// sharing or filing: the query, the match confidence, every person with
+// collapsible sections (see Response.WriteReport; an error response is
+// reported as such), links to the sources, and a footer with the search ID,
+// the time of the search and the parameters used. The response holds neither
+// of the last two, so pass them in the options: without a Timestamp the footer
+// shows when the report was generated instead, and without Parameters it notes
+// that they weren't recorded. Images are referenced by URL; the report holds no
+// other external assets. Options may be nil.
+func (response *Response) WriteHTMLReport(w io.Writer, options *HTMLReportOptions) error {
+	report := htmlReport{
+		Title:     "Pipl report",
+		CSS:       template.CSS(htmlReportCSS),
+		Query:     reportQuery(&response.Query),
+		Search:    searchSection(response),
+		Timestamp: reportLine{Label: "Report generated", Text: time.Now().UTC().Format(time.RFC3339)},
+	}
+	if options != nil {
+		if options.Title != "" {
+			report.Title = options.Title
+		}
+		if !options.Timestamp.IsZero() {
+			report.Timestamp = reportLine{Label: "Searched", Text: options.Timestamp.UTC().Format(time.RFC3339)}
+		}
+		if options.Parameters != nil {
+			values := options.Parameters.values()
+			names := make([]string, 0, len(values))
+			for name := range values {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			for _, name := range names {
+				report.Parameters = append(report.Parameters, reportLine{Label: name, Text: values.Get(name)})
+			}
+		}
+	}
+
+	persons := response.AllPersons()
+	switch err := response.Err(); {
+	case err != nil:
+		report.Outcome = "Error: " + err.Error()
+	case response.IsEmpty():
+		report.Outcome = "No match"
+	case response.HasFullProfile():
+		report.Outcome = "Full profile"
+		if response.Person.Match != 0 {
+			report.Outcome += ", match " + reportPercent(response.Person.Match)
+		}
+	default:
+		report.Outcome = strconv.Itoa(len(persons)) + " possible persons"
+		report.Header, report.Rows = comparisonHeader, comparisonRows(persons)
+	}
+	for i, person := range persons {
+		title := reportTitle(person)
+		if len(persons) > 1 {
+			title = strconv.Itoa(i+1) + ". " + title
+		}
+		htmlPerson := htmlPerson{
+			Title: title,
+			Match: reportPercent(person.Match),
+			Open:  len(persons) == 1,
+		}
+		for _, image := range person.Images {
+			if image.URL != "" {
+				htmlPerson.Images = append(htmlPerson.Images, image.URL)
+			}
+		}
+		for _, section := range personSections(person, response.sourcesOf(person)) {
+			if len(section.Lines) > 0 {
+				htmlPerson.Sections = append(htmlPerson.Sections, section)
+			}
+		}
+		report.Persons = append(report.Persons, htmlPerson)
+	}
+	return htmlReportTemplate.Execute(w, report)
+}
+
+// htmlReportCSS styles the HTML report. It is inlined so the report stands
+// alone.
+const htmlReportCSS = `
+body { font: 15px/1.5 -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; color: #1f2328; max-width: 60em; margin: 2em auto; padding: 0 1em; }
+h1 { font-size: 1.6em; margin-bottom: 0.2em; }
+.query { color: #59636e; margin-top: 0; }
+.outcome { font-weight: 600; }
+table { border-collapse: collapse; margin: 1em 0; }
+th, td { border: 1px solid #d1d9e0; padding: 0.3em 0.7em; text-align: left; }
+th { background: #f6f8fa; }
+details.person { border: 1px solid #d1d9e0; border-radius: 6px; margin: 1em 0; padding: 0.5em 1em; }
+details.person > summary { font-size: 1.2em; font-weight: 600; cursor: pointer; }
+details.section > summary { font-weight: 600; cursor: pointer; margin-top: 0.6em; }
+.match { color: #59636e; font-weight: normal; }
+.images img { max-height: 120px; margin: 0.5em 0.5em 0 0; border-radius: 4px; }
+ul, dl { margin: 0.3em 0 0.3em 1.5em; padding: 0; }
+dt { font-weight: 600; float: left; clear: left; margin-right: 0.5em; }
+dt::after { content: ":"; }
+dd { margin: 0; }
+.marker { font-size: 0.8em; background: #eff2f5; border-radius: 1em; padding: 0 0.6em; margin-left: 0.3em; color: #59636e; }
+.marker.current { background: #dafbe1; color: #116329; }
+.marker.inferred { background: #fff8c5; color: #7d4e00; }
+footer { border-top: 1px solid #d1d9e0; margin-top: 2em; padding-top: 0.5em; font-size: 0.85em; color: #59636e; }
+`
+
+var htmlReportTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}</title>
+<style>{{.CSS}}</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+{{if .Query}}<p class="query">Query: {{.Query}}</p>{{end}}
+<p class="outcome">{{.Outcome}}</p>
+{{if .Rows}}<table>
+<tr>{{range .Header}}<th>{{.}}</th>{{end}}</tr>
+{{range .Rows}}<tr>{{range .}}<td>{{.}}</td>{{end}}</tr>
+{{end}}</table>{{end}}
+{{range .Persons}}<details class="person"{{if .Open}} open{{end}}>
+<summary>{{.Title}}{{if .Match}} <span class="match">{{.Match}}</span>{{end}}</summary>
+{{if .Images}}<div class="images">{{range .Images}}<img src="{{.}}" alt="" loading="lazy" referrerpolicy="no-referrer">{{end}}</div>{{end}}
+{{range .Sections}}{{template "section" .}}{{end}}</details>
+{{end}}
+<footer>
+{{with .Search}}{{if .Lines}}<dl>{{range .Lines}}<dt>{{.Label}}</dt><dd>{{.Text}}</dd>{{end}}</dl>{{end}}{{end}}
+<dl><dt>{{.Timestamp.Label}}</dt><dd>{{.Timestamp.Text}}</dd>{{range .Parameters}}<dt>{{.Label}}</dt><dd>{{.Text}}</dd>{{else}}<dt>Parameters</dt><dd>not recorded</dd>{{end}}</dl>
+</footer>
+</body>
+</html>
+{{define "section"}}<details class="section" open>
+<summary>{{.Title}}</summary>
+{{if .Fields}}<dl>{{range .Lines}}<dt>{{.Label}}</dt><dd>{{.Text}}</dd>{{end}}</dl>
+{{else}}<ul>
+{{range .Lines}}<li>{{if .Label}}{{.Label}}: {{end}}{{if .URL}}<a href="{{.URL}}" rel="noopener noreferrer">{{or .Text .URL}}</a>{{else}}{{.Text}}{{end}}{{range .Markers}}<span class="marker{{if eq . "current"}} current{{else if eq . "inferred"}} inferred{{end}}">{{.}}</span>{{end}}</li>
+{{end}}</ul>
+{{end}}</details>
+{{end}}`))
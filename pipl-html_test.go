@@ -0,0 +1,77 @@
+package pipl_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/xpcmdshell/pipl"
+)
+
+func TestHTMLReport(t *testing.T) {
+	response, _ := loadResponse(t, "person_response.json")
+	response.Person.URLs = append(response.Person.URLs, pipl.URL{Name: "Trap", URL: "javascript:alert(1)"})
+	response.Person.Names[0].Display = "<script>Clark</script>"
+	parameters := pipl.NewClient("secret").SearchParameters
+	parameters.MatchRequirements = "email"
+	options := &pipl.HTMLReportOptions{
+		Title:      "Case 42",
+		Parameters: parameters,
+		Timestamp:  time.Date(2019, 7, 19, 15, 23, 19, 0, time.UTC),
+	}
+	var buffer bytes.Buffer
+	if err := response.WriteHTMLReport(&buffer, options); err != nil {
+		t.Fatal(err)
+	}
+	report := buffer.String()
+	for _, expected := range []string{
+		"<title>Case 42</title>",
+		"<p class=\"outcome\">Full profile, match 100%</p>",
+		"<details class=\"person\" open>",
+		"<summary>&lt;script&gt;Clark&lt;/script&gt; <span class=\"match\">100%</span></summary>",
+		`<img src="http://www.example.com/superman.jpg"`,
+		`<a href="https://www.linkedin.com/pub/superman/20/7a/365" rel="noopener noreferrer">LinkedIn</a>`,
+		`<span class="marker current">current</span>`,
+		"<dt>Search ID</dt><dd>1907191523190584356574718927436437981</dd>",
+		"<dt>Searched</dt><dd>2019-07-19T15:23:19Z</dd>",
+		"<dt>match_requirements</dt><dd>email</dd>",
+	} {
+		if !strings.Contains(report, expected) {
+			t.Errorf("expected %q in the report:\n%s", expected, report)
+		}
+	}
+	for _, unexpected := range []string{"secret", "<script>", "javascript:", "<link", "<script src"} {
+		if strings.Contains(report, unexpected) {
+			t.Errorf("unexpected %q in the report", unexpected)
+		}
+	}
+}
+
+func TestHTMLReportAmbiguous(t *testing.T) {
+	response, _ := loadResponse(t, "possible_persons_response.json")
+	var buffer bytes.Buffer
+	if err := response.WriteHTMLReport(&buffer, nil); err != nil {
+		t.Fatal(err)
+	}
+	report := buffer.String()
+	if !strings.Contains(report, "<p class=\"outcome\">2 possible persons</p>") ||
+		!strings.Contains(report, "<tr><td>2</td><td>28%</td><td>Clark Kent</td>") ||
+		strings.Count(report, "<details class=\"person\">") != 2 ||
+		!strings.Contains(report, "<dt>Report generated</dt>") || !strings.Contains(report, "<dt>Parameters</dt><dd>not recorded</dd>") {
+		t.Errorf("expected a comparison table, two collapsed persons and a footer without search details:\n%s", report)
+	}
+}
+
+func TestHTMLReportError(t *testing.T) {
+	response, _ := loadResponse(t, "error_response.json")
+	var buffer bytes.Buffer
+	if err := response.WriteHTMLReport(&buffer, nil); err != nil {
+		t.Fatal(err)
+	}
+	report := buffer.String()
+	if !strings.Contains(report, "<p class=\"outcome\">Error: The API key is invalid or has exceeded its quota</p>") ||
+		!strings.Contains(report, "<dt>HTTP status</dt><dd>403</dd>") {
+		t.Errorf("expected the API error in the report:\n%s", report)
+	}
+}
@@ -0,0 +1,259 @@
+package pipl
+
+import "encoding/json"
+
+// SchemaOrgContext is the schema.org JSON-LD context, the vocabulary of the
+// documents built by JSONLD.
+const SchemaOrgContext = "https://schema.org"
+
+// PiplVocabulary is the IRI of the "pipl:" prefix, which JSONLD uses for the
+// data schema.org has no property for: the validity of values (pipl:validSince,
+// pipl:lastSeen, pipl:current and pipl:inferred) and the type of addresses
+// (pipl:addressType).
+const PiplVocabulary = "urn:pipl:vocab:"
+
+// jsonLDNode is a JSON-LD object under construction.
+type jsonLDNode map[string]interface{}
+
+// set stores value under key, unless it is empty.
+func (node jsonLDNode) set(key string, value interface{}) {
+	switch typed := value.(type) {
+	case string:
+		if typed == "" {
+			return
+		}
+	case []interface{}:
+		if len(typed) == 0 {
+			return
+		}
+	case jsonLDNode:
+		if len(typed) == 0 {
+			return
+		}
+	}
+	node[key] = value
+}
+
+// annotate records the validity of a value with the pipl: extension
+// properties, since schema.org has no property for it.
+func (node jsonLDNode) annotate(validity Validity) {
+	node.set("pipl:validSince", string(validity.ValidSince))
+	node.set("pipl:lastSeen", string(validity.LastSeen))
+	if validity.Current {
+		node["pipl:current"] = true
+	}
+	if validity.Inferred {
+		node["pipl:inferred"] = true
+	}
+}
+
+// jsonLDValidity describes the validity of a plain property of a person, such
+// as name or birthDate, which can't be annotated in place. It returns nil if
+// there is nothing to record.
+func jsonLDValidity(property string, value string, validity Validity) jsonLDNode {
+	if validity == (Validity{}) {
+		return nil
+	}
+	node := jsonLDNode{"@type": "pipl:Validity", "pipl:property": property}
+	node.set("pipl:value", value)
+	node.annotate(validity)
+	return node
+}
+
+// period sets the startDate and endDate of a role from a date range.
+func (node jsonLDNode) period(dateRange *DateRange) {
+	if dateRange != nil {
+		node.set("startDate", string(dateRange.Start))
+		node.set("endDate", string(dateRange.End))
+	}
+}
+
+// jsonLDRole wraps value in a schema.org Role, the way schema.org qualifies a
+// relation (e.g. worksFor) with a role name, dates and annotations.
+func jsonLDRole(roleType string, property string, value interface{}, roleName string, validity Validity) jsonLDNode {
+	node := jsonLDNode{"@type": roleType, property: value}
+	node.set("roleName", roleName)
+	node.annotate(validity)
+	return node
+}
+
+// ToJSONLD encodes the result of JSONLD.
+func (person *Person) ToJSONLD() ([]byte, error) {
+	return json.Marshal(person.JSONLD())
+}
+
+// JSONLD renders the person as a schema.org Person in JSON-LD, ready to be
+// encoded with encoding/json. The first name maps to givenName, familyName and
+// friends and the others to alternateName; jobs map to worksFor (an
+// EmployeeRole holding the title and dates) and jobTitle; educations to
+// alumniOf (an OrganizationRole holding the degree); addresses to PostalAddress;
+// phones and emails to telephone, email and ContactPoints; URLs to sameAs;
+// and relationships to relatedTo for family and knows for everyone else, as a
+// Role holding the relationship subtype. schema.org has no property for the
+// validity of values, so it is kept with the pipl: extension properties (see
+// PiplVocabulary): on the ContactPoints, PostalAddresses and Roles themselves,
+// and for names, gender and birth date in pipl:validity.
+func (person *Person) JSONLD() map[string]interface{} {
+	node := personJSONLD(&person.PersonFields)
+	node["@context"] = []interface{}{SchemaOrgContext, map[string]interface{}{"pipl": PiplVocabulary}}
+	if person.ID != "" {
+		node["@id"] = "urn:pipl:" + string(person.ID)
+		node["identifier"] = string(person.ID)
+	}
+	return node
+}
+
+func personJSONLD(fields *PersonFields) jsonLDNode {
+	node := jsonLDNode{"@type": "Person"}
+	var alternateNames, validities []interface{}
+	addValidity := func(property string, value string, validity Validity) {
+		if annotated := jsonLDValidity(property, value, validity); annotated != nil {
+			validities = append(validities, annotated)
+		}
+	}
+	for i, name := range fields.Names {
+		if i > 0 {
+			if label := name.label(); label != "" {
+				alternateNames = append(alternateNames, label)
+				addValidity("alternateName", label, name.Validity)
+			}
+			continue
+		}
+		addValidity("name", name.label(), name.Validity)
+		node.set("name", name.label())
+		node.set("givenName", name.First)
+		node.set("additionalName", name.Middle)
+		node.set("familyName", name.Last)
+		node.set("honorificPrefix", name.Prefix)
+		node.set("honorificSuffix", name.Suffix)
+	}
+	node.set("alternateName", alternateNames)
+
+	var emails, telephones, contactPoints []interface{}
+	for _, email := range fields.Emails {
+		if email.Address == "" {
+			continue
+		}
+		emails = append(emails, email.Address)
+		contactPoint := jsonLDNode{"@type": "ContactPoint", "email": email.Address}
+		contactPoint.set("contactType", string(email.Type))
+		contactPoint.annotate(email.Validity)
+		contactPoints = append(contactPoints, contactPoint)
+	}
+	for _, phone := range fields.Phones {
+		label := phone.label()
+		if label == "" {
+			continue
+		}
+		telephones = append(telephones, label)
+		contactPoint := jsonLDNode{"@type": "ContactPoint", "telephone": label}
+		contactPoint.set("contactType", string(phone.Type))
+		contactPoint.annotate(phone.Validity)
+		contactPoints = append(contactPoints, contactPoint)
+	}
+	node.set("email", emails)
+	node.set("telephone", telephones)
+	node.set("contactPoint", contactPoints)
+
+	var addresses []interface{}
+	for _, address := range fields.Addresses {
+		postal := jsonLDNode{"@type": "PostalAddress"}
+		street := joinNonEmpty(" ", address.House, address.Street)
+		if address.Apartment != "" {
+			street = joinNonEmpty(" ", street, "#"+address.Apartment)
+		}
+		postal.set("streetAddress", street)
+		postal.set("postOfficeBoxNumber", address.POBox)
+		postal.set("addressLocality", address.City)
+		postal.set("addressRegion", address.State)
+		postal.set("postalCode", address.ZipCode)
+		postal.set("addressCountry", address.Country)
+		postal.set("name", address.label())
+		postal.set("pipl:addressType", string(address.Type))
+		postal.annotate(address.Validity)
+		addresses = append(addresses, postal)
+	}
+	node.set("address", addresses)
+
+	var employers, titles []interface{}
+	for _, job := range fields.Jobs {
+		if job.Title != "" {
+			titles = append(titles, job.Title)
+		}
+		if job.Organization == "" {
+			continue
+		}
+		employer := jsonLDRole("EmployeeRole", "worksFor", jsonLDNode{"@type": "Organization", "name": job.Organization}, job.Title, job.Validity)
+		employer.period(job.DateRange)
+		employers = append(employers, employer)
+	}
+	node.set("worksFor", employers)
+	node.set("jobTitle", titles)
+
+	var schools []interface{}
+	for _, education := range fields.Educations {
+		if education.School == "" {
+			continue
+		}
+		school := jsonLDRole("OrganizationRole", "alumniOf", jsonLDNode{"@type": "EducationalOrganization", "name": education.School}, education.Degree, education.Validity)
+		school.period(education.DateRange)
+		schools = append(schools, school)
+	}
+	node.set("alumniOf", schools)
+
+	var sameAs, images, languages []interface{}
+	for _, url := range fields.URLs {
+		if url.URL != "" {
+			sameAs = append(sameAs, url.URL)
+		}
+	}
+	for _, image := range fields.Images {
+		if image.URL != "" {
+			images = append(images, image.URL)
+		}
+	}
+	for _, language := range fields.Languages {
+		if language.Language != "" {
+			languages = append(languages, joinNonEmpty("-", language.Language, language.Region))
+		}
+	}
+	node.set("sameAs", sameAs)
+	node.set("image", images)
+	node.set("knowsLanguage", languages)
+
+	if fields.Gender != nil {
+		switch fields.Gender.Content {
+		case GenderMale:
+			node.set("gender", "https://schema.org/Male")
+		case GenderFemale:
+			node.set("gender", "https://schema.org/Female")
+		}
+		if _, ok := node["gender"]; ok {
+			addValidity("gender", "", fields.Gender.Validity)
+		}
+	}
+	if dob := fields.DateOfBirth; dob != nil && dob.DateRange != nil && dob.DateRange.Start == dob.DateRange.End {
+		node.set("birthDate", string(dob.DateRange.Start))
+		addValidity("birthDate", "", dob.Validity)
+	}
+	node.set("pipl:validity", validities)
+
+	var relatives, acquaintances []interface{}
+	for i := range fields.Relationships {
+		relationship := &fields.Relationships[i]
+		property := "knows"
+		if relationship.Type == RelationshipTypeFamily {
+			property = "relatedTo"
+		}
+		relative := jsonLDRole("Role", property, personJSONLD(&relationship.PersonFields), string(relationship.Subtype), relationship.Validity)
+		relative.set("description", string(relationship.Type))
+		if property == "relatedTo" {
+			relatives = append(relatives, relative)
+		} else {
+			acquaintances = append(acquaintances, relative)
+		}
+	}
+	node.set("relatedTo", relatives)
+	node.set("knows", acquaintances)
+	return node
+}
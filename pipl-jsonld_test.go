@@ -0,0 +1,91 @@
+package pipl_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/xpcmdshell/pipl"
+)
+
+func TestJSONLD(t *testing.T) {
+	response, _ := loadResponse(t, "person_response.json")
+	data, err := response.Person.ToJSONLD()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var document struct {
+		Context    []interface{} `json:"@context"`
+		Type       string        `json:"@type"`
+		GivenName  string        `json:"givenName"`
+		FamilyName string        `json:"familyName"`
+		Telephone  []string
+		WorksFor   []struct {
+			Type     string `json:"@type"`
+			RoleName string `json:"roleName"`
+			WorksFor struct {
+				Name string `json:"name"`
+			} `json:"worksFor"`
+			ValidSince string `json:"pipl:validSince"`
+		} `json:"worksFor"`
+		Address []struct {
+			Type            string `json:"@type"`
+			AddressLocality string `json:"addressLocality"`
+			ContactType     string `json:"contactType"`
+			AddressType     string `json:"pipl:addressType"`
+		} `json:"address"`
+		RelatedTo []struct {
+			RoleName  string `json:"roleName"`
+			RelatedTo struct {
+				Name string `json:"name"`
+			} `json:"relatedTo"`
+		} `json:"relatedTo"`
+		Knows []struct {
+			Knows struct {
+				Email []string `json:"email"`
+			} `json:"knows"`
+		} `json:"knows"`
+		SameAs   []string `json:"sameAs"`
+		Validity []struct {
+			Property string `json:"pipl:property"`
+			Current  bool   `json:"pipl:current"`
+		} `json:"pipl:validity"`
+	}
+	if err := json.Unmarshal(data, &document); err != nil {
+		t.Fatal(err)
+	}
+	if len(document.Context) != 2 || document.Context[0] != "https://schema.org" || document.Type != "Person" || document.GivenName != "Clark" || document.FamilyName != "Kent" {
+		t.Errorf("unexpected person: %s", data)
+	}
+	if len(document.Telephone) != 2 || len(document.SameAs) != 2 || len(document.Address) != 2 || document.Address[0].Type != "PostalAddress" ||
+		document.Address[0].ContactType != "" || document.Address[0].AddressType != "home" {
+		t.Errorf("unexpected contact data: %s", data)
+	}
+	if len(document.WorksFor) != 1 || document.WorksFor[0].Type != "EmployeeRole" || document.WorksFor[0].WorksFor.Name != "Daily Planet" ||
+		document.WorksFor[0].ValidSince == "" {
+		t.Errorf("unexpected jobs: %+v", document.WorksFor)
+	}
+	if len(document.Validity) != 1 || document.Validity[0].Property != "name" {
+		t.Errorf("unexpected validity: %+v", document.Validity)
+	}
+	if len(document.RelatedTo) != 1 || document.RelatedTo[0].RoleName != "Father" || document.RelatedTo[0].RelatedTo.Name != "Jonathan Kent" {
+		t.Errorf("unexpected family: %+v", document.RelatedTo)
+	}
+	if len(document.Knows) != 1 || len(document.Knows[0].Knows.Email) != 1 {
+		t.Errorf("unexpected acquaintances: %+v", document.Knows)
+	}
+}
+
+func TestJSONLDValidity(t *testing.T) {
+	person := pipl.NewPerson()
+	person.Gender = &pipl.Gender{Content: pipl.GenderFemale, Validity: pipl.Validity{Inferred: true}}
+	person.DateOfBirth = &pipl.DateOfBirth{DateRange: &pipl.DateRange{Start: "1986-06-18", End: "1986-06-18"}, Validity: pipl.Validity{Current: true}}
+	validity, _ := person.JSONLD()["pipl:validity"].([]interface{})
+	if len(validity) != 2 {
+		t.Fatalf("expected the validity of gender and birthDate, got %v", validity)
+	}
+	data, _ := json.Marshal(validity)
+	if !strings.Contains(string(data), `"pipl:property":"gender"`) || !strings.Contains(string(data), `"pipl:property":"birthDate"`) {
+		t.Errorf("unexpected validity: %s", data)
+	}
+}
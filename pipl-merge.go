@@ -0,0 +1,175 @@
+package pipl
+
+import "encoding/json"
+
+// MergeAction describes what happened to a single value during a merge.
+type MergeAction string
+
+const (
+	// MergeAdded means the value was new and was added to the profile
+	MergeAdded MergeAction = "added"
+
+	// MergeDuplicate means the profile already held the same value (by
+	// normalised comparison) with equal or stronger validity, so it was kept
+	MergeDuplicate MergeAction = "duplicate"
+
+	// MergeReplaced means the profile already held the same value, or a
+	// different value for a single-valued field (gender, dob), but the merged
+	// value had stronger validity and replaced it
+	MergeReplaced MergeAction = "replaced"
+
+	// MergeConflict means a single-valued field (gender, dob) held a different
+	// value with equal or stronger validity, so the merged value was dropped
+	MergeConflict MergeAction = "conflict"
+)
+
+// MergeEntry records what happened to one value during a merge. Key is the
+// normalised form used to compare values and Value a human-readable form.
+type MergeEntry struct {
+	Category FieldCategory `json:"category"`
+	Action   MergeAction   `json:"action"`
+	Key      string        `json:"key"`
+	Value    string        `json:"value"`
+}
+
+// MergeReport lists everything a merge did, in order.
+type MergeReport struct {
+	Entries []MergeEntry `json:"entries"`
+}
+
+// Count returns the number of entries with the given action.
+func (report *MergeReport) Count(action MergeAction) int {
+	count := 0
+	for _, entry := range report.Entries {
+		if entry.Action == action {
+			count++
+		}
+	}
+	return count
+}
+
+func (report *MergeReport) add(category FieldCategory, action MergeAction, item fieldItem) {
+	report.Entries = append(report.Entries, MergeEntry{Category: category, Action: action, Key: item.key(), Value: item.label()})
+}
+
+// Merge folds the data of other into the person. Values are compared by their
+// normalised form (case, punctuation and phone formatting are ignored), so each
+// value is only kept once. When both persons hold the same value, the copy with
+// the strongest validity is kept: current over stale, most recently seen, then
+// observed over inferred. Relatives found in both are merged recursively. other
+// is never modified, and the merged person shares no data with it.
+func (person *Person) Merge(other *Person) *MergeReport {
+	report := new(MergeReport)
+	if person.ID == "" {
+		person.ID = other.ID
+	}
+	if person.SearchPointer == "" {
+		person.SearchPointer = other.SearchPointer
+	}
+	if other.Match > person.Match {
+		person.Match = other.Match
+	}
+	person.Inferred = person.Inferred && other.Inferred
+	for key, value := range other.Extra {
+		if _, ok := person.Extra[key]; !ok {
+			if person.Extra == nil {
+				person.Extra = make(map[string]json.RawMessage, len(other.Extra))
+			}
+			person.Extra[key] = value
+		}
+	}
+	mergeFields(&person.PersonFields, other.PersonFields.clone(), report)
+	return report
+}
+
+// MergePersons merges several records of the same individual into a single,
+// deduplicated profile, which shares no data with the records it was built
+// from. Duplicates within a single record are folded together as well.
+func MergePersons(persons ...*Person) (*Person, *MergeReport) {
+	merged := new(Person)
+	merged.Inferred = len(persons) > 0
+	report := new(MergeReport)
+	for _, person := range persons {
+		report.Entries = append(report.Entries, merged.Merge(person).Entries...)
+	}
+	return merged, report
+}
+
+// mergeFields folds src into dst. src must not share data with anything else,
+// since its values are moved into dst as-is.
+func mergeFields(dst *PersonFields, src PersonFields, report *MergeReport) {
+	for _, category := range FieldCategories {
+		incoming := src.items(category)
+		if len(incoming) == 0 {
+			continue
+		}
+		existing := dst.items(category)
+		if personFieldIndex[category].single {
+			existing = mergeSingle(category, existing, incoming[0], report)
+		} else {
+			for _, item := range incoming {
+				existing = mergeItem(category, existing, item, report)
+			}
+		}
+		dst.setItems(category, existing)
+	}
+}
+
+// mergeSingle merges the value of a single-valued field such as gender.
+func mergeSingle(category FieldCategory, existing []fieldItem, item fieldItem, report *MergeReport) []fieldItem {
+	if len(existing) == 0 {
+		report.add(category, MergeAdded, item)
+		return []fieldItem{item}
+	}
+	if stronger(item, existing[0]) {
+		report.add(category, MergeReplaced, item)
+		return []fieldItem{item}
+	}
+	if item.key() == existing[0].key() {
+		report.add(category, MergeDuplicate, item)
+	} else {
+		report.add(category, MergeConflict, item)
+	}
+	return existing
+}
+
+// mergeItem merges a single value of a multi-valued field into existing.
+func mergeItem(category FieldCategory, existing []fieldItem, item fieldItem, report *MergeReport) []fieldItem {
+	key := item.key()
+	if key == "" {
+		return existing
+	}
+	for i, current := range existing {
+		if current.key() != key {
+			continue
+		}
+		if relationship, ok := item.(*Relationship); ok {
+			target := current.(*Relationship)
+			mergeFields(&target.PersonFields, relationship.PersonFields, new(MergeReport))
+			if relationship.Validity.strongerThan(target.Validity) {
+				target.Validity = relationship.Validity
+			}
+			report.add(category, MergeDuplicate, item)
+			return existing
+		}
+		if stronger(item, current) {
+			existing[i] = item
+			report.add(category, MergeReplaced, item)
+		} else {
+			report.add(category, MergeDuplicate, item)
+		}
+		return existing
+	}
+	report.add(category, MergeAdded, item)
+	return append(existing, item)
+}
+
+// stronger reports whether item has stronger validity than current. Values
+// without validity (tags) are never stronger.
+func stronger(item fieldItem, current fieldItem) bool {
+	validity, currentValidity := validityOf(item), validityOf(current)
+	if validity == nil || currentValidity == nil {
+		return false
+	}
+	return validity.strongerThan(*currentValidity)
+}
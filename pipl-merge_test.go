@@ -0,0 +1,68 @@
+package pipl_test
+
+import (
+	"testing"
+
+	"github.com/xpcmdshell/pipl"
+)
+
+func TestMergePersons(t *testing.T) {
+	older := pipl.NewPerson()
+	older.ID = "a7a2a3a1"
+	older.AddName("Clark", "", "Kent", "", "")
+	older.AddEmail("Clark.Kent@example.com")
+	older.Phones = []pipl.Phone{{CountryCode: 1, Number: 9785550145, Validity: pipl.Validity{LastSeen: "2014-01-01"}}}
+	older.SetGender(pipl.GenderMale)
+	older.AddRelationship(pipl.Relationship{Type: pipl.RelationshipTypeFriend, PersonFields: pipl.PersonFields{Names: []pipl.Name{{First: "Lois", Last: "Lane"}}}})
+
+	newer := pipl.NewPerson()
+	newer.AddNameRaw("clark kent")
+	newer.AddEmail("clark.kent@example.com")
+	newer.AddEmail("clark@dailyplanet.example.com")
+	newer.Phones = []pipl.Phone{{Raw: "+1 (978) 555-0145", Validity: pipl.Validity{Current: true, LastSeen: "2017-03-02"}}}
+	newer.SetGender(pipl.GenderFemale)
+	newer.AddRelationship(pipl.Relationship{Type: pipl.RelationshipTypeFriend, PersonFields: pipl.PersonFields{
+		Names:  []pipl.Name{{First: "Lois", Last: "Lane"}},
+		Emails: []pipl.Email{{Address: "lois@dailyplanet.example.com"}},
+	}})
+
+	merged, report := pipl.MergePersons(older, newer)
+	if merged.ID != "a7a2a3a1" {
+		t.Errorf("unexpected ID %q", merged.ID)
+	}
+	if len(merged.Names) != 1 || len(merged.Emails) != 2 || len(merged.Phones) != 1 || len(merged.Relationships) != 1 {
+		t.Fatalf("values were not deduplicated: %+v", merged.PersonFields)
+	}
+	if !merged.Phones[0].Current || merged.Phones[0].Raw == "" {
+		t.Errorf("expected the current phone to win: %+v", merged.Phones[0])
+	}
+	if merged.Gender.Content != pipl.GenderMale {
+		t.Errorf("conflicting gender with equal validity should keep the first value, got %q", merged.Gender.Content)
+	}
+	if len(merged.Relationships[0].Emails) != 1 {
+		t.Error("relatives should be merged recursively")
+	}
+	if report.Count(pipl.MergeReplaced) != 1 || report.Count(pipl.MergeConflict) != 1 || report.Count(pipl.MergeDuplicate) != 3 {
+		t.Errorf("unexpected report: %+v", report.Entries)
+	}
+
+	merged.Relationships[0].Emails[0].Address = "changed"
+	if newer.Relationships[0].Emails[0].Address != "lois@dailyplanet.example.com" {
+		t.Error("merged person should not share data with its inputs")
+	}
+}
+
+func TestMergePhoneForms(t *testing.T) {
+	person := pipl.NewPerson()
+	person.Phones = []pipl.Phone{
+		{Number: 9785550145},
+		{Raw: "978-555-0145"},
+		{CountryCode: 1, Number: 6175550123, Extension: 12},
+		{Raw: "001 617 555 0123 ext. 12"},
+		{Raw: "617 555 0123"},
+	}
+	merged, _ := pipl.MergePersons(person)
+	if len(merged.Phones) != 3 {
+		t.Errorf("expected 3 phones, got %+v", merged.Phones)
+	}
+}
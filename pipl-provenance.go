@@ -0,0 +1,115 @@
+package pipl
+
+// SourceRef identifies a source which asserted a piece of data.
+type SourceRef struct {
+	ID        string `json:"id"`
+	Name      string `json:"name,omitempty"`
+	Domain    string `json:"domain,omitempty"`
+	Category  string `json:"category,omitempty"`
+	OriginURL string `json:"origin_url,omitempty"`
+	Premium   bool   `json:"premium,omitempty"`
+	Sponsored bool   `json:"sponsored,omitempty"`
+}
+
+// FieldProvenance links a single value of a person (e.g. person.Emails[1]) to
+// the sources it appeared in.
+type FieldProvenance struct {
+	Category FieldCategory `json:"category"`
+	Index    int           `json:"index"`
+	Label    string        `json:"label"`
+	Sources  []SourceRef   `json:"sources"`
+}
+
+// Provenance maps each value of a person back to the sources that asserted it.
+// The number of sources behind a value is a useful corroboration signal: a value
+// reported by a single source is weaker than one reported by five.
+type Provenance struct {
+	Fields []FieldProvenance `json:"fields"`
+}
+
+// BuildProvenance matches every value of person against the values held by
+// sources, using the same normalised comparison as Merge. Sources belonging to
+// a different person (by @person_id) are ignored. Sources are only returned by
+// searches run with ShowSources set to ShowSourcesMatching or ShowSourcesAll.
+func BuildProvenance(person *Person, sources []Source) *Provenance {
+	provenance := new(Provenance)
+	for _, category := range FieldCategories {
+		for index, item := range person.PersonFields.items(category) {
+			field := FieldProvenance{Category: category, Index: index, Label: item.label()}
+			for i := range sources {
+				source := &sources[i]
+				if person.ID != "" && source.PersonID != "" && source.PersonID != person.ID {
+					continue
+				}
+				if assertedBy(item, category, source) {
+					field.Sources = append(field.Sources, source.ref())
+				}
+			}
+			provenance.Fields = append(provenance.Fields, field)
+		}
+	}
+	return provenance
+}
+
+// Provenance builds the provenance of one of the persons in the response from
+// the response's sources.
+func (response *Response) Provenance(person *Person) *Provenance {
+	return BuildProvenance(person, response.Sources)
+}
+
+// assertedBy reports whether source holds item. URLs also name their source
+// directly through @source_id.
+func assertedBy(item fieldItem, category FieldCategory, source *Source) bool {
+	if url, ok := item.(*URL); ok && url.SourceID != "" && url.SourceID == source.ID {
+		return true
+	}
+	key := item.key()
+	if key == "" {
+		return false
+	}
+	for _, candidate := range source.PersonFields.items(category) {
+		if candidate.key() == key {
+			return true
+		}
+	}
+	return false
+}
+
+func (source *Source) ref() SourceRef {
+	return SourceRef{
+		ID:        source.ID,
+		Name:      source.Name,
+		Domain:    source.Domain,
+		Category:  source.Category,
+		OriginURL: source.OriginURL,
+		Premium:   source.Premium,
+		Sponsored: source.Sponsored,
+	}
+}
+
+// Sources returns the sources behind the value at person.<category>[index].
+func (provenance *Provenance) Sources(category FieldCategory, index int) []SourceRef {
+	for _, field := range provenance.Fields {
+		if field.Category == category && field.Index == index {
+			return field.Sources
+		}
+	}
+	return nil
+}
+
+// Count returns the number of sources behind the value at person.<category>[index].
+func (provenance *Provenance) Count(category FieldCategory, index int) int {
+	return len(provenance.Sources(category, index))
+}
+
+// Counts returns the number of sources behind each value of a category, in the
+// order the values appear in the person.
+func (provenance *Provenance) Counts(category FieldCategory) []int {
+	var counts []int
+	for _, field := range provenance.Fields {
+		if field.Category == category {
+			counts = append(counts, len(field.Sources))
+		}
+	}
+	return counts
+}
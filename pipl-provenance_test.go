@@ -0,0 +1,36 @@
+package pipl_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/xpcmdshell/pipl"
+)
+
+func TestProvenance(t *testing.T) {
+	response, _ := loadResponse(t, "person_response.json")
+	provenance := response.Provenance(&response.Person)
+
+	if counts := provenance.Counts(pipl.CategoryPhones); !reflect.DeepEqual(counts, []int{1, 0}) {
+		t.Errorf("unexpected phone counts: %v", counts)
+	}
+	phoneSources := provenance.Sources(pipl.CategoryPhones, 0)
+	if len(phoneSources) != 1 || phoneSources[0].Domain != "whitepages.com" || !phoneSources[0].Premium {
+		t.Errorf("unexpected phone sources: %+v", phoneSources)
+	}
+	if provenance.Count(pipl.CategoryUsernames, 0) != 1 || provenance.Count(pipl.CategoryImages, 0) != 1 {
+		t.Error("expected the username and image to be linked to Facebook")
+	}
+	if urlSources := provenance.Sources(pipl.CategoryURLs, 0); len(urlSources) != 1 || urlSources[0].Name != "LinkedIn" {
+		t.Errorf("expected the URL to be linked through its source ID: %+v", urlSources)
+	}
+	if provenance.Count(pipl.CategoryJobs, 0) != 1 {
+		t.Error("expected the job to be linked to LinkedIn")
+	}
+
+	other := response.Person
+	other.ID = "someone-else"
+	if pipl.BuildProvenance(&other, response.Sources).Count(pipl.CategoryPhones, 0) != 0 {
+		t.Error("sources of another person should be ignored")
+	}
+}
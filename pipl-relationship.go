@@ -0,0 +1,110 @@
+package pipl
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ErrRelationshipNotSearchable is an error type returned by SearchQuery and
+// SearchRelationship when a relationship doesn't hold enough data to search for
+// the relative. Reason explains what's missing.
+type ErrRelationshipNotSearchable struct {
+	Reason string
+}
+
+func (err *ErrRelationshipNotSearchable) Error() string {
+	return "The relationship can't be searched: " + err.Reason
+}
+
+// ToPerson converts the relationship into a Person holding the relative's
+// data. The person shares no data with the relationship.
+func (relationship Relationship) ToPerson() *Person {
+	return &Person{PersonFields: relationship.PersonFields.clone()}
+}
+
+// SearchQuery builds a search object for the relative, keeping only the data
+// that makes useful search terms: names, emails, phones, usernames, user IDs,
+// URLs, addresses, date of birth and gender. Jobs, educations, images, tags and
+// the relative's own relationships are dropped, as are type values Pipl doesn't
+// document (which SearchByPerson would refuse). If the result doesn't meet the
+// minimum search criteria, an *ErrRelationshipNotSearchable explains why.
+func (relationship Relationship) SearchQuery() (*Person, error) {
+	query := new(Person)
+	query.Names = relationship.Names
+	query.Emails = relationship.Emails
+	query.Phones = relationship.Phones
+	query.Usernames = relationship.Usernames
+	query.UserIDs = relationship.UserIDs
+	query.URLs = relationship.URLs
+	query.Addresses = relationship.Addresses
+	query.DateOfBirth = relationship.DateOfBirth
+	query.Gender = relationship.Gender
+	query.PersonFields = query.PersonFields.clone()
+	if query.Gender != nil && !query.Gender.Content.IsKnown() {
+		query.Gender = nil
+	}
+	for i := range query.Names {
+		if !query.Names[i].Type.IsKnown() {
+			query.Names[i].Type = ""
+		}
+	}
+	for i := range query.Emails {
+		if !query.Emails[i].Type.IsKnown() {
+			query.Emails[i].Type = ""
+		}
+	}
+	for i := range query.Phones {
+		if !query.Phones[i].Type.IsKnown() {
+			query.Phones[i].Type = ""
+		}
+	}
+	for i := range query.Addresses {
+		if !query.Addresses[i].Type.IsKnown() {
+			query.Addresses[i].Type = ""
+		}
+	}
+	if !meetsMinimumCriteria(query) {
+		return nil, &ErrRelationshipNotSearchable{Reason: relationship.unsearchableReason()}
+	}
+	return query, nil
+}
+
+// unsearchableReason describes why a relationship fails the minimum search
+// criteria (see meetsMinimumCriteria).
+func (relationship Relationship) unsearchableReason() string {
+	var held []string
+	for _, name := range relationship.Names {
+		if label := name.label(); label != "" {
+			held = append(held, fmt.Sprintf("a partial name (%q)", label))
+		}
+	}
+	for _, phone := range relationship.Phones {
+		if label := phone.label(); label != "" {
+			held = append(held, fmt.Sprintf("a phone without a country code (%q)", label))
+		}
+	}
+	if len(relationship.Addresses) > 0 {
+		held = append(held, "an address")
+	}
+	if relationship.DateOfBirth != nil || relationship.Gender != nil {
+		held = append(held, "a date of birth or gender")
+	}
+	if len(relationship.Jobs) > 0 || len(relationship.Educations) > 0 {
+		held = append(held, "jobs or educations")
+	}
+	requirement := "a full name, email, phone, username, user ID or URL is required"
+	if len(held) == 0 {
+		return "it holds no data about the relative; " + requirement
+	}
+	return "it only holds " + strings.Join(held, ", ") + "; " + requirement
+}
+
+// SearchRelationship searches for the relative described by a relationship,
+// using the search terms from SearchQuery.
+func (searchClient *Client) SearchRelationship(relationship *Relationship) (*Response, error) {
+	query, err := relationship.SearchQuery()
+	if err != nil {
+		return nil, err
+	}
+	return searchClient.SearchByPerson(query)
+}
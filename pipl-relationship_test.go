@@ -0,0 +1,46 @@
+package pipl_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/xpcmdshell/pipl"
+)
+
+func TestRelationshipSearchQuery(t *testing.T) {
+	response, _ := loadResponse(t, "person_response.json")
+
+	lois := response.Person.Relationships[1]
+	lois.Jobs = []pipl.Job{{Title: "Reporter"}}
+	lois.Names[0].Type = "nickname"
+	query, err := lois.SearchQuery()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(query.Names) != 1 || len(query.Emails) != 1 || query.Gender == nil || len(query.Jobs) != 0 {
+		t.Errorf("unexpected query: %+v", query.PersonFields)
+	}
+	if query.Names[0].Type != "" || query.Validate() != nil {
+		t.Error("undocumented types should be dropped from the query")
+	}
+	query.Emails[0].Address = "changed"
+	if lois.Emails[0].Address == "changed" {
+		t.Error("the query should not share data with the relationship")
+	}
+	if person := lois.ToPerson(); len(person.Jobs) != 1 {
+		t.Error("ToPerson should keep every field")
+	}
+
+	father := pipl.Relationship{Type: pipl.RelationshipTypeFamily, Subtype: "Father"}
+	father.Names = []pipl.Name{{First: "Jonathan"}}
+	_, err = father.SearchQuery()
+	notSearchable, ok := err.(*pipl.ErrRelationshipNotSearchable)
+	if !ok || !strings.Contains(notSearchable.Reason, `a partial name ("Jonathan")`) {
+		t.Errorf("expected an explanation, got %v", err)
+	}
+
+	client := pipl.NewClient("test")
+	if _, err := client.SearchRelationship(&father); err == nil || err.Error() != notSearchable.Error() {
+		t.Errorf("SearchRelationship should refuse the relationship without searching, got %v", err)
+	}
+}
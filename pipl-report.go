@@ -0,0 +1,462 @@
+package pipl
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"unicode/utf8"
+)
+
+// ReportFormat selects the markup of a profile report.
+type ReportFormat string
+
+const (
+	// ReportText renders plain text, with underlined headings
+	ReportText ReportFormat = "text"
+
+	// ReportMarkdown renders Markdown
+	ReportMarkdown ReportFormat = "markdown"
+)
+
+// reportWriter renders headings, lists and tables in either format.
+type reportWriter struct {
+	format ReportFormat
+	out    bytes.Buffer
+}
+
+// heading writes a heading; level 1 is the title.
+func (report *reportWriter) heading(level int, text string) {
+	if report.out.Len() > 0 && !bytes.HasSuffix(report.out.Bytes(), []byte("\n\n")) {
+		report.out.WriteByte('\n')
+	}
+	if report.format == ReportMarkdown {
+		fmt.Fprintf(&report.out, "%s %s\n\n", strings.Repeat("#", level), report.escape(text))
+		return
+	}
+	underline := "~"
+	switch level {
+	case 1:
+		underline = "="
+	case 2:
+		underline = "-"
+	}
+	fmt.Fprintf(&report.out, "%s\n%s\n\n", text, strings.Repeat(underline, utf8.RuneCountInString(text)))
+}
+
+// field writes a "label: value" line.
+func (report *reportWriter) field(label string, value string) {
+	if value == "" {
+		return
+	}
+	if report.format == ReportMarkdown {
+		fmt.Fprintf(&report.out, "- **%s:** %s\n", label, report.escape(value))
+		return
+	}
+	fmt.Fprintf(&report.out, "  %s: %s\n", label, value)
+}
+
+// table writes a table with a header row.
+func (report *reportWriter) table(header []string, rows [][]string) {
+	if report.format == ReportMarkdown {
+		fmt.Fprintf(&report.out, "| %s |\n", strings.Join(header, " | "))
+		fmt.Fprintf(&report.out, "|%s\n", strings.Repeat(" --- |", len(header)))
+		for _, row := range rows {
+			cells := make([]string, len(row))
+			for i, cell := range row {
+				cells[i] = strings.Replace(report.escape(cell), "|", `\|`, -1)
+			}
+			fmt.Fprintf(&report.out, "| %s |\n", strings.Join(cells, " | "))
+		}
+		return
+	}
+	table := tabwriter.NewWriter(&report.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(table, "  %s\n", strings.Join(header, "\t"))
+	for _, row := range rows {
+		fmt.Fprintf(table, "  %s\n", strings.Join(row, "\t"))
+	}
+	table.Flush()
+}
+
+// escape protects Markdown syntax characters in text.
+func (report *reportWriter) escape(text string) string {
+	if report.format != ReportMarkdown {
+		return text
+	}
+	var escaped strings.Builder
+	for _, r := range text {
+		if strings.ContainsRune("\\`*_[]<>#", r) {
+			escaped.WriteByte('\\')
+		}
+		escaped.WriteRune(r)
+	}
+	return escaped.String()
+}
+
+// link renders text pointing at url; plain text shows both.
+func (report *reportWriter) link(text string, url string) string {
+	switch {
+	case url == "":
+		return report.escape(text)
+	case report.format != ReportMarkdown:
+		return joinNonEmpty(" ", text, url)
+	case text == "" || text == url:
+		return "<" + url + ">"
+	}
+	url = strings.NewReplacer("(", "%28", ")", "%29", " ", "%20").Replace(url)
+	return "[" + report.escape(text) + "](" + url + ")"
+}
+
+// line renders a value of a section: its label, the value itself (linked to
+// its URL) and its markers, e.g. "+1 978-555-0145 (phone, mobile, current)".
+func (report *reportWriter) line(line reportLine) string {
+	text := report.link(line.Text, line.URL)
+	if line.Label != "" {
+		text = report.escape(line.Label+": ") + text
+	}
+	if len(line.Markers) == 0 {
+		return text
+	}
+	if report.format == ReportMarkdown {
+		return text + " _(" + report.escape(strings.Join(line.Markers, ", ")) + ")_"
+	}
+	return text + " (" + strings.Join(line.Markers, ", ") + ")"
+}
+
+// section writes a section headed at the given level, skipping empty sections.
+func (report *reportWriter) section(level int, section reportSection) {
+	if len(section.Lines) == 0 {
+		return
+	}
+	report.heading(level, section.Title)
+	for _, line := range section.Lines {
+		switch {
+		case section.Fields:
+			report.field(line.Label, line.Text)
+		case report.format == ReportMarkdown:
+			fmt.Fprintf(&report.out, "- %s\n", report.line(line))
+		default:
+			fmt.Fprintf(&report.out, "  - %s\n", report.line(line))
+		}
+	}
+}
+
+// WriteReport writes a sectioned, human-readable profile of the person: names,
+// personal details, contact information, addresses, career and education
+// timelines, relationships, social profiles and match confidence.
+func (person *Person) WriteReport(w io.Writer, format ReportFormat) error {
+	report, err := newReportWriter(format)
+	if err != nil {
+		return err
+	}
+	report.person(person, nil, 1)
+	_, err = w.Write(report.out.Bytes())
+	return err
+}
+
+// WriteReport writes a human-readable report of the response. A full profile
+// is rendered as by Person.WriteReport, along with its sources; possible
+// persons are compared side by side in a table, followed by their profiles.
+// An error response is reported as such, rather than as no match. The report
+// ends with the query, the search ID and any warnings.
+func (response *Response) WriteReport(w io.Writer, format ReportFormat) error {
+	report, err := newReportWriter(format)
+	if err != nil {
+		return err
+	}
+	switch err := response.Err(); {
+	case err != nil:
+		report.heading(1, "Error: "+err.Error())
+	case response.IsEmpty():
+		report.heading(1, "No match")
+	case response.HasFullProfile():
+		report.person(&response.Person, response.sourcesOf(&response.Person), 1)
+	default:
+		persons := response.AllPersons()
+		report.heading(1, strconv.Itoa(len(persons))+" possible persons")
+		report.table(comparisonHeader, comparisonRows(persons))
+		for i, person := range persons {
+			report.person(person, response.sourcesOf(person), 2, strconv.Itoa(i+1))
+		}
+	}
+	report.section(2, searchSection(response))
+	_, err = w.Write(report.out.Bytes())
+	return err
+}
+
+func newReportWriter(format ReportFormat) (*reportWriter, error) {
+	if format != ReportText && format != ReportMarkdown {
+		return nil, fmt.Errorf("pipl: unknown report format %q", string(format))
+	}
+	return &reportWriter{format: format}, nil
+}
+
+// sourcesOf returns the sources of one of the persons of the response. A full
+// profile is backed by every source when the sources don't name a person.
+func (response *Response) sourcesOf(person *Person) Sources {
+	if person.ID != "" {
+		if sources := response.Sources.ForPerson(person.ID); len(sources) > 0 {
+			return sources
+		}
+	}
+	if person == &response.Person {
+		return response.Sources
+	}
+	return nil
+}
+
+// person writes the profile of a person, headed at the given level. number
+// prefixes the title of possible persons.
+func (report *reportWriter) person(person *Person, sources Sources, level int, number ...string) {
+	title := reportTitle(person)
+	if len(number) > 0 {
+		title = number[0] + ". " + title
+	}
+	report.heading(level, title)
+	for _, section := range personSections(person, sources) {
+		report.section(level+1, section)
+	}
+}
+
+// reportLine is a value in a report: the value, an optional label (such as
+// "Gender" or a date range), the URL it links to, and markers such as the
+// value's type and whether it is current or inferred.
+type reportLine struct {
+	Label   string
+	Text    string
+	URL     string
+	Markers []string
+}
+
+// reportSection is a titled list of values. The values of Fields sections are
+// "label: value" pairs rather than list items.
+type reportSection struct {
+	Title  string
+	Fields bool
+	Lines  []reportLine
+}
+
+// valueLine describes a field value, with its details as markers.
+func valueLine(item fieldItem, details ...string) reportLine {
+	markers := make([]string, 0, len(details)+2)
+	for _, detail := range details {
+		if detail != "" {
+			markers = append(markers, strings.Replace(detail, "_", " ", -1))
+		}
+	}
+	if validity := validityOf(item); validity != nil {
+		if validity.Current {
+			markers = append(markers, "current")
+		}
+		if validity.Inferred {
+			markers = append(markers, "inferred")
+		}
+	}
+	return reportLine{Text: item.label(), Markers: markers}
+}
+
+// itemSection describes the values of a category, skipping empty values.
+func itemSection(title string, items []fieldItem, describe func(fieldItem) reportLine) reportSection {
+	section := reportSection{Title: title}
+	for _, item := range items {
+		if item.label() != "" {
+			section.Lines = append(section.Lines, describe(item))
+		}
+	}
+	return section
+}
+
+// reportTitle names a person for the title of a report.
+func reportTitle(person *Person) string {
+	if len(person.Names) > 0 {
+		return firstNonEmpty(person.Names[0].label(), "(no name)")
+	}
+	return "(no name)"
+}
+
+// personSections describes the profile of a person section by section: names,
+// personal details, contact information, addresses, career and education
+// timelines, relationships, social profiles, sources and confidence. Sections
+// may be empty.
+func personSections(person *Person, sources Sources) []reportSection {
+	details := reportSection{Title: "Personal details"}
+	for _, category := range []FieldCategory{CategoryGender, CategoryDateOfBirth, CategoryLanguages, CategoryEthnicities, CategoryOriginCountries} {
+		for _, item := range person.items(category) {
+			line := valueLine(item)
+			line.Label = reportCategoryLabels[category]
+			details.Lines = append(details.Lines, line)
+		}
+	}
+	contacts := reportSection{Title: "Contact information"}
+	for _, item := range person.items(CategoryEmails) {
+		contacts.Lines = append(contacts.Lines, valueLine(item, "email", string(item.(*Email).Type)))
+	}
+	for _, item := range person.items(CategoryPhones) {
+		contacts.Lines = append(contacts.Lines, valueLine(item, "phone", string(item.(*Phone).Type)))
+	}
+	for _, item := range person.items(CategoryUsernames) {
+		contacts.Lines = append(contacts.Lines, valueLine(item, "username"))
+	}
+	for _, item := range person.items(CategoryUserIDs) {
+		contacts.Lines = append(contacts.Lines, valueLine(item, "user ID"))
+	}
+
+	sourceLines := reportSection{Title: "Sources"}
+	for _, source := range sources.SortByMatch() {
+		markers := []string{strings.Replace(source.Category, "_", " ", -1)}
+		if source.Match != 0 {
+			markers = append(markers, "match "+reportPercent(source.Match))
+		}
+		if source.Premium {
+			markers = append(markers, "premium")
+		}
+		if source.Sponsored {
+			markers = append(markers, "sponsored")
+		}
+		sourceLines.Lines = append(sourceLines.Lines, reportLine{Text: firstNonEmpty(source.Name, source.Domain), URL: source.OriginURL, Markers: markers})
+	}
+
+	confidence := reportSection{Title: "Confidence", Fields: true}
+	confidence.Lines = append(confidence.Lines, reportLine{Label: "Match", Text: firstNonEmpty(reportPercent(person.Match), "not scored")})
+	if person.Inferred {
+		confidence.Lines = append(confidence.Lines, reportLine{Label: "Inferred", Text: "this person was inferred by statistical analysis"})
+	}
+	if len(sources) > 0 {
+		confidence.Lines = append(confidence.Lines, reportLine{Label: "Sources", Text: strconv.Itoa(len(sources))})
+	}
+	if person.SearchPointer != "" {
+		confidence.Lines = append(confidence.Lines, reportLine{Label: "Search pointer", Text: person.SearchPointer})
+	}
+
+	return []reportSection{
+		itemSection("Names", person.items(CategoryNames), func(item fieldItem) reportLine {
+			return valueLine(item, string(item.(*Name).Type))
+		}),
+		details,
+		contacts,
+		itemSection("Addresses", person.items(CategoryAddresses), func(item fieldItem) reportLine {
+			return valueLine(item, string(item.(*Address).Type))
+		}),
+		itemSection("Career", timeline(person.items(CategoryJobs)), datedLine),
+		itemSection("Education", timeline(person.items(CategoryEducations)), datedLine),
+		itemSection("Relationships", person.items(CategoryRelationships), func(item fieldItem) reportLine {
+			relationship := item.(*Relationship)
+			return valueLine(item, firstNonEmpty(string(relationship.Subtype), string(relationship.Type)))
+		}),
+		itemSection("Social profiles", person.items(CategoryURLs), func(item fieldItem) reportLine {
+			url := item.(*URL)
+			line := valueLine(item, url.Category)
+			line.Text, line.URL = firstNonEmpty(url.Name, url.Domain), url.URL
+			return line
+		}),
+		sourceLines,
+		confidence,
+	}
+}
+
+// searchSection describes the search behind a response: the query, the search
+// ID, the number of sources and any warnings.
+func searchSection(response *Response) reportSection {
+	section := reportSection{Title: "Search", Fields: true}
+	add := func(label string, text string) {
+		if text != "" {
+			section.Lines = append(section.Lines, reportLine{Label: label, Text: text})
+		}
+	}
+	add("Query", reportQuery(&response.Query))
+	add("Search ID", response.SearchID)
+	if response.Err() != nil && response.HTTPStatusCode != 0 {
+		add("HTTP status", strconv.Itoa(response.HTTPStatusCode))
+	}
+	if response.AvailableSources != 0 {
+		add("Sources", fmt.Sprintf("%d visible of %d available", response.VisibleSources, response.AvailableSources))
+	}
+	for _, warning := range response.Warnings {
+		add("Warning", warning)
+	}
+	return section
+}
+
+// datedLine describes a job or education, labelled with its date range.
+func datedLine(item fieldItem) reportLine {
+	line := valueLine(item)
+	if dates := dateRangeOf(item); dates != nil {
+		line.Label = dates.label()
+	}
+	return line
+}
+
+// comparisonHeader is the header of the table comparing possible persons.
+var comparisonHeader = []string{"#", "Match", "Name", "Age", "Location", "Job"}
+
+// comparisonRows lists the main traits of possible persons, one row each.
+func comparisonRows(persons []*Person) [][]string {
+	rows := make([][]string, 0, len(persons))
+	for i, person := range persons {
+		row := []string{strconv.Itoa(i + 1), reportPercent(person.Match)}
+		for _, category := range []FieldCategory{CategoryNames, CategoryDateOfBirth, CategoryAddresses, CategoryJobs} {
+			cell := ""
+			if items := person.items(category); len(items) > 0 {
+				cell = items[0].label()
+			}
+			row = append(row, cell)
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// reportCategoryLabels names the personal details.
+var reportCategoryLabels = map[FieldCategory]string{
+	CategoryGender:          "Gender",
+	CategoryDateOfBirth:     "Age",
+	CategoryLanguages:       "Language",
+	CategoryEthnicities:     "Ethnicity",
+	CategoryOriginCountries: "Origin country",
+}
+
+// timeline sorts jobs or educations by start date, most recent first. Undated
+// entries keep their order, after the dated ones.
+func timeline(items []fieldItem) []fieldItem {
+	sort.SliceStable(items, func(i, j int) bool {
+		first, second := dateRangeOf(items[i]), dateRangeOf(items[j])
+		if first == nil || second == nil {
+			return second == nil && first != nil
+		}
+		return first.Start > second.Start
+	})
+	return items
+}
+
+// dateRangeOf returns the date range of a job or education.
+func dateRangeOf(item fieldItem) *DateRange {
+	switch typed := item.(type) {
+	case *Job:
+		return typed.DateRange
+	case *Education:
+		return typed.DateRange
+	}
+	return nil
+}
+
+// reportQuery summarises a search query on one line.
+func reportQuery(query *Person) string {
+	var labels []string
+	for _, category := range FieldCategories {
+		for _, item := range query.items(category) {
+			labels = append(labels, item.label())
+		}
+	}
+	return joinNonEmpty(", ", labels...)
+}
+
+// reportPercent formats a match confidence as a percentage.
+func reportPercent(match float32) string {
+	if match == 0 {
+		return ""
+	}
+	return strconv.FormatFloat(float64(match)*100, 'f', 0, 32) + "%"
+}
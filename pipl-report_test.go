@@ -0,0 +1,106 @@
+package pipl_test
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/xpcmdshell/pipl"
+)
+
+func TestReportText(t *testing.T) {
+	response, _ := loadResponse(t, "person_response.json")
+	var buffer bytes.Buffer
+	if err := response.WriteReport(&buffer, pipl.ReportText); err != nil {
+		t.Fatal(err)
+	}
+	report := buffer.String()
+	if !strings.HasPrefix(report, "Clark Joseph Kent\n=================\n") {
+		t.Errorf("expected the name as title:\n%s", report)
+	}
+	for _, expected := range []string{
+		"Contact information\n-------------------\n",
+		"  - +1 978-555-0145 (phone, mobile, current)\n",
+		"  - 344-3D Clinton St, Metropolis, New York (old)\n",
+		"  - 2008-04-01: Field Reporter at Daily Planet",
+		"  - Jonathan Kent (Father)\n",
+		"  - Facebook https://www.facebook.com/superman (personal profiles)\n",
+		"  Match: 100%\n",
+		"  Search ID: 1907191523190584356574718927436437981\n",
+	} {
+		if !strings.Contains(report, expected) {
+			t.Errorf("expected %q in the report:\n%s", expected, report)
+		}
+	}
+	if err := response.WriteReport(&buffer, "pdf"); err == nil {
+		t.Error("expected an error for an unknown format")
+	}
+}
+
+func TestReportMarkdownAmbiguous(t *testing.T) {
+	response, _ := loadResponse(t, "possible_persons_response.json")
+	var buffer bytes.Buffer
+	if err := response.WriteReport(&buffer, pipl.ReportMarkdown); err != nil {
+		t.Fatal(err)
+	}
+	report := buffer.String()
+	for _, expected := range []string{
+		"# 2 possible persons\n\n| # | Match | Name | Age | Location | Job |\n",
+		"| 2 | 28% | Clark Kent | 64-69 years old | Los Angeles, California |  |\n",
+		"## 1. Clark J Kent\n",
+		"### Confidence\n\n- **Match:** 72%\n",
+		"- **Warning:** Search for names only may produce many matches\n",
+	} {
+		if !strings.Contains(report, expected) {
+			t.Errorf("expected %q in the report:\n%s", expected, report)
+		}
+	}
+}
+
+func TestReportError(t *testing.T) {
+	response, _ := loadResponse(t, "error_response.json")
+	var buffer bytes.Buffer
+	if err := response.WriteReport(&buffer, pipl.ReportMarkdown); err != nil {
+		t.Fatal(err)
+	}
+	report := buffer.String()
+	if !strings.HasPrefix(report, "# Error: The API key is invalid or has exceeded its quota\n") ||
+		!strings.Contains(report, "- **HTTP status:** 403\n") || strings.Contains(report, "No match") {
+		t.Errorf("expected the API error in the report:\n%s", report)
+	}
+}
+
+func TestReportTimeline(t *testing.T) {
+	person := pipl.Person{}
+	person.Jobs = []pipl.Job{
+		{Title: "Intern", Organization: "Daily Planet", DateRange: &pipl.DateRange{Start: "2003-06-01", End: "2003-09-01"}},
+		{Title: "Hero", Validity: pipl.Validity{Inferred: true}},
+		{Title: "Field Reporter", Organization: "Daily Planet", DateRange: &pipl.DateRange{Start: "2008-04-01"}},
+	}
+	var buffer bytes.Buffer
+	if err := person.WriteReport(&buffer, pipl.ReportMarkdown); err != nil {
+		t.Fatal(err)
+	}
+	expected := "## Career\n\n" +
+		"- 2008-04-01: Field Reporter at Daily Planet\n" +
+		"- 2003-06-01 - 2003-09-01: Intern at Daily Planet\n" +
+		"- Hero _(inferred)_\n"
+	if !strings.Contains(buffer.String(), expected) {
+		t.Errorf("expected %q in the report:\n%s", expected, buffer.String())
+	}
+}
+
+func TestFieldString(t *testing.T) {
+	address := pipl.Address{House: "10", Street: "Hickory Lane", City: "Smallville", State: "KS", Country: "US"}
+	if printed := fmt.Sprint(&address); printed != "10 Hickory Lane, Smallville, KS, US" {
+		t.Errorf("unexpected address %q", printed)
+	}
+	phone := pipl.Phone{CountryCode: 1, Number: 9785550145, Extension: 12}
+	if printed := fmt.Sprint(&phone); printed != "+1 9785550145 x12" {
+		t.Errorf("unexpected phone %q", printed)
+	}
+	if printed := fmt.Sprintf("%+v", phone); !strings.Contains(printed, "CountryCode:1") {
+		t.Errorf("values should keep the default struct formatting, got %q", printed)
+	}
+}
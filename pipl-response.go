@@ -0,0 +1,76 @@
+package pipl
+
+// Pipl answers a search in one of three shapes: no match (PersonsCount == 0),
+// a single full profile in Person (PersonsCount == 1), or several partial
+// profiles in PossiblePersons (PersonsCount > 1). Partial profiles carry a
+// SearchPointer which can be passed to SearchByPointer to get the full profile.
+// The helpers below hide those rules from callers.
+
+// Err returns an *ErrAPI if the Pipl service answered with an error (Error is
+// set or the status is 400 or above), and nil otherwise. Such responses hold no
+// persons, so check Err before IsEmpty.
+func (response *Response) Err() error {
+	if response.Error == "" && response.HTTPStatusCode < 400 {
+		return nil
+	}
+	return &ErrAPI{StatusCode: response.HTTPStatusCode, Message: response.Error}
+}
+
+// IsEmpty reports whether the search didn't match anyone.
+func (response *Response) IsEmpty() bool {
+	return len(response.AllPersons()) == 0
+}
+
+// HasFullProfile reports whether the search matched a single person, whose
+// full profile is held in Person.
+func (response *Response) HasFullProfile() bool {
+	return response.PersonsCount == 1 || (response.PersonsCount == 0 && !response.Person.IsEmpty())
+}
+
+// IsAmbiguous reports whether the search matched several possible persons.
+// Each of them is a partial profile, see SearchByPointer.
+func (response *Response) IsAmbiguous() bool {
+	return !response.HasFullProfile() && len(response.PossiblePersons) > 1
+}
+
+// AllPersons returns every person in the response as a single slice: the full
+// profile if there is one, the possible persons otherwise. The pointers refer
+// to the response itself.
+func (response *Response) AllPersons() []*Person {
+	if response.HasFullProfile() {
+		return []*Person{&response.Person}
+	}
+	persons := make([]*Person, 0, len(response.PossiblePersons))
+	for i := range response.PossiblePersons {
+		persons = append(persons, &response.PossiblePersons[i])
+	}
+	return persons
+}
+
+// Best returns the person with the highest match confidence, and whether that
+// person is a full profile (complete) or a partial one which needs a follow up
+// SearchByPointer to fill in. It returns nil if the search didn't match anyone.
+func (response *Response) Best() (person *Person, complete bool) {
+	for _, candidate := range response.AllPersons() {
+		if person == nil || candidate.Match > person.Match {
+			person = candidate
+		}
+	}
+	return person, person != nil && response.HasFullProfile()
+}
+
+// IsEmpty reports whether the person holds no data at all, as when
+// SearchByPointer is given a pointer that no longer resolves. Every field
+// category counts, so a person holding only a job or a relationship isn't
+// empty.
+func (person *Person) IsEmpty() bool {
+	if person.ID != "" || person.SearchPointer != "" {
+		return false
+	}
+	for _, category := range FieldCategories {
+		if len(person.PersonFields.items(category)) > 0 {
+			return false
+		}
+	}
+	return true
+}
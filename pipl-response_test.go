@@ -0,0 +1,74 @@
+package pipl_test
+
+import (
+	"testing"
+
+	"github.com/xpcmdshell/pipl"
+)
+
+func TestResponseShapes(t *testing.T) {
+	full, _ := loadResponse(t, "person_response.json")
+	if !full.HasFullProfile() || full.IsAmbiguous() || full.IsEmpty() {
+		t.Error("expected a single full profile")
+	}
+	if persons := full.AllPersons(); len(persons) != 1 || persons[0] != &full.Person {
+		t.Errorf("unexpected persons: %v", persons)
+	}
+	if best, complete := full.Best(); best != &full.Person || !complete {
+		t.Error("expected the full profile to be the best match")
+	}
+
+	possible, _ := loadResponse(t, "possible_persons_response.json")
+	if possible.HasFullProfile() || !possible.IsAmbiguous() || possible.IsEmpty() {
+		t.Error("expected an ambiguous response")
+	}
+	if len(possible.AllPersons()) != 2 {
+		t.Errorf("expected 2 persons, got %d", len(possible.AllPersons()))
+	}
+	best, complete := possible.Best()
+	if best == nil || best.Match != 0.72 || complete {
+		t.Errorf("unexpected best match: %+v (complete: %v)", best, complete)
+	}
+
+	empty := new(pipl.Response)
+	if !empty.IsEmpty() || empty.HasFullProfile() || empty.IsAmbiguous() {
+		t.Error("expected an empty response")
+	}
+	if best, _ := empty.Best(); best != nil {
+		t.Error("expected no best match in an empty response")
+	}
+}
+
+func TestResponseErr(t *testing.T) {
+	rejected, _ := loadResponse(t, "error_response.json")
+	err, ok := rejected.Err().(*pipl.ErrAPI)
+	if !ok || err.StatusCode != 403 || err.Error() != "The API key is invalid or has exceeded its quota" {
+		t.Errorf("unexpected error: %v", rejected.Err())
+	}
+	if full, _ := loadResponse(t, "person_response.json"); full.Err() != nil {
+		t.Errorf("unexpected error: %v", full.Err())
+	}
+}
+
+func TestPersonIsEmpty(t *testing.T) {
+	if !new(pipl.Person).IsEmpty() {
+		t.Error("expected a zero person to be empty")
+	}
+	full, _ := loadResponse(t, "person_response.json")
+	if full.Person.IsEmpty() {
+		t.Error("expected a full profile not to be empty")
+	}
+	if (&pipl.Person{SearchPointer: "0123456789abcdef"}).IsEmpty() {
+		t.Error("expected a person with a search pointer not to be empty")
+	}
+	jobOnly := new(pipl.Person)
+	jobOnly.AddJob("Field Reporter", "Daily Planet", "", "", "")
+	if jobOnly.IsEmpty() || !(&pipl.Response{Person: *jobOnly}).HasFullProfile() {
+		t.Error("expected a person holding only a job not to be empty")
+	}
+	gender := new(pipl.Person)
+	gender.Gender = &pipl.Gender{Content: pipl.GenderFemale}
+	if gender.IsEmpty() {
+		t.Error("expected a person holding only a gender not to be empty")
+	}
+}
@@ -0,0 +1,114 @@
+package pipl
+
+import "sort"
+
+// Sources is a list of sources, as returned in Response.Sources when a search
+// is run with ShowSources. Its methods never modify the list they're called on;
+// the filtering methods return a new list, which shares its sources with the
+// original.
+type Sources []Source
+
+// Filter returns the sources for which keep returns true.
+func (sources Sources) Filter(keep func(source *Source) bool) Sources {
+	var kept Sources
+	for i := range sources {
+		if keep(&sources[i]) {
+			kept = append(kept, sources[i])
+		}
+	}
+	return kept
+}
+
+// ByPerson groups the sources by the person they belong to (@person_id).
+func (sources Sources) ByPerson() map[GUID]Sources {
+	groups := make(map[GUID]Sources)
+	for _, source := range sources {
+		groups[source.PersonID] = append(groups[source.PersonID], source)
+	}
+	return groups
+}
+
+// ForPerson returns the sources belonging to a single person.
+func (sources Sources) ForPerson(personID GUID) Sources {
+	return sources.Filter(func(source *Source) bool { return source.PersonID == personID })
+}
+
+// WithCategories returns the sources in one of the given categories
+// (e.g. "professional_and_business"). This is an allow list.
+func (sources Sources) WithCategories(categories ...string) Sources {
+	allowed := stringSet(categories)
+	return sources.Filter(func(source *Source) bool { return allowed[source.Category] })
+}
+
+// WithoutCategories returns the sources in none of the given categories. This
+// is a deny list.
+func (sources Sources) WithoutCategories(categories ...string) Sources {
+	denied := stringSet(categories)
+	return sources.Filter(func(source *Source) bool { return !denied[source.Category] })
+}
+
+// WithDomains returns the sources from one of the given domains (e.g. "linkedin.com").
+func (sources Sources) WithDomains(domains ...string) Sources {
+	allowed := stringSet(domains)
+	return sources.Filter(func(source *Source) bool { return allowed[source.Domain] })
+}
+
+// ExcludeSponsored returns the sources that aren't sponsored.
+func (sources Sources) ExcludeSponsored() Sources {
+	return sources.Filter(func(source *Source) bool { return !source.Sponsored })
+}
+
+// Split divides the sources into basic, premium and sponsored ones. Sponsored
+// sources are only listed as sponsored, even if they're also premium.
+func (sources Sources) Split() (basic Sources, premium Sources, sponsored Sources) {
+	for _, source := range sources {
+		switch {
+		case source.Sponsored:
+			sponsored = append(sponsored, source)
+		case source.Premium:
+			premium = append(premium, source)
+		default:
+			basic = append(basic, source)
+		}
+	}
+	return basic, premium, sponsored
+}
+
+// SortByMatch returns a copy of the sources ordered by match confidence, best
+// first. Sources with equal confidence keep their original order.
+func (sources Sources) SortByMatch() Sources {
+	sorted := make(Sources, len(sources))
+	copy(sorted, sources)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Match > sorted[j].Match })
+	return sorted
+}
+
+// ToPerson rebuilds a person from the data held by the sources, merging and
+// deduplicating it as MergePersons does. This is typically used on a filtered
+// list, e.g. to see a profile built from non-sponsored sources only. The
+// person's ID is set if all the sources belong to the same person.
+func (sources Sources) ToPerson() *Person {
+	persons := make([]*Person, 0, len(sources))
+	for _, source := range sources {
+		persons = append(persons, &Person{PersonFields: source.PersonFields})
+	}
+	person, _ := MergePersons(persons...)
+	if groups := sources.ByPerson(); len(groups) == 1 {
+		for personID := range groups {
+			person.ID = personID
+		}
+	}
+	if len(sources) > 0 {
+		person.Match = sources.SortByMatch()[0].Match
+	}
+	return person
+}
+
+// stringSet builds a set out of a list of strings.
+func stringSet(values []string) map[string]bool {
+	set := make(map[string]bool, len(values))
+	for _, value := range values {
+		set[value] = true
+	}
+	return set
+}
@@ -0,0 +1,35 @@
+package pipl_test
+
+import (
+	"testing"
+)
+
+func TestSources(t *testing.T) {
+	response, _ := loadResponse(t, "person_response.json")
+	sources := response.Sources
+
+	if groups := sources.ByPerson(); len(groups) != 1 || len(groups[response.Person.ID]) != 3 {
+		t.Errorf("unexpected grouping: %v", groups)
+	}
+	basic, premium, sponsored := sources.Split()
+	if len(basic) != 1 || len(premium) != 1 || len(sponsored) != 1 {
+		t.Errorf("unexpected split: %d basic, %d premium, %d sponsored", len(basic), len(premium), len(sponsored))
+	}
+	if kept := sources.ExcludeSponsored(); len(kept) != 2 {
+		t.Errorf("expected 2 non-sponsored sources, got %d", len(kept))
+	}
+	if kept := sources.WithCategories("professional_and_business"); len(kept) != 1 || kept[0].Domain != "linkedin.com" {
+		t.Errorf("unexpected allow list result: %v", kept)
+	}
+	if kept := sources.WithoutCategories("professional_and_business", "personal_profiles"); len(kept) != 1 || kept[0].Domain != "whitepages.com" {
+		t.Errorf("unexpected deny list result: %v", kept)
+	}
+	if sorted := sources.SortByMatch(); sorted[2].Domain != "whitepages.com" || sources[2].Domain != "whitepages.com" {
+		t.Error("expected the lowest match last, without modifying the original")
+	}
+
+	person := sources.ExcludeSponsored().ToPerson()
+	if person.ID != response.Person.ID || len(person.Names) != 1 || len(person.Usernames) != 0 || len(person.Phones) != 1 {
+		t.Errorf("unexpected rebuilt person: %+v", person)
+	}
+}
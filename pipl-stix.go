@@ -0,0 +1,273 @@
+package pipl
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// STIXNamespace is the UUID namespace STIX 2.1 uses to derive the IDs of cyber
+// observables (email-addr, user-account) from their properties.
+const STIXNamespace = "00abedb4-aa42-466c-9c01-fed23315a9b7"
+
+// piplSTIXNamespace is the UUID namespace used for the IDs of the identities,
+// relationships and bundles built from Pipl data: the version 5 UUID of
+// "pipl.com" in the DNS namespace.
+var piplSTIXNamespace = uuidV5("6ba7b810-9dad-11d1-80b4-00c04fd430c8", "pipl.com")
+
+// STIXOptions controls the STIX export.
+type STIXOptions struct {
+	// Timestamp is used as the created and modified time of the identities and
+	// relationships. It defaults to the time of the export.
+	Timestamp time.Time
+}
+
+// STIXObject is a single STIX object, keyed by its JSON property names.
+type STIXObject map[string]interface{}
+
+// ID returns the STIX ID of the object, e.g. "identity--...".
+func (object STIXObject) ID() string {
+	id, _ := object["id"].(string)
+	return id
+}
+
+// Type returns the STIX type of the object, e.g. "identity".
+func (object STIXObject) Type() string {
+	objectType, _ := object["type"].(string)
+	return objectType
+}
+
+// STIXBundle is a STIX 2.1 bundle, ready to be encoded with encoding/json.
+type STIXBundle struct {
+	Type    string       `json:"type"`
+	ID      string       `json:"id"`
+	Objects []STIXObject `json:"objects"`
+}
+
+// STIXBundle converts the persons of the response (see AllPersons) and their
+// relatives into a STIX 2.1 bundle. Every person becomes an individual
+// identity; their emails become email-addr observables and their usernames and
+// user IDs user-account observables, each linked to the identity by a
+// "related-to" relationship, as are relatives. IDs are deterministic: the
+// observables' follow the STIX rules for deterministic IDs, and identities are
+// keyed on the Pipl person ID (or the person's emails, phones... or search
+// pointer when it has none), so exporting the same data twice yields the same
+// IDs and TIPs can deduplicate them. Persons with none of these, such as
+// relatives known only by name, are keyed on their position and content within
+// the export (for relatives, their relationship entry and the identity of the
+// person holding it), so they stay deterministic without two "John Smith"s
+// collapsing into one identity. Options may be nil.
+func (response *Response) STIXBundle(options *STIXOptions) *STIXBundle {
+	return NewSTIXBundle(options, response.AllPersons()...)
+}
+
+// NewSTIXBundle converts persons into a STIX 2.1 bundle, as Response.STIXBundle
+// does. Options may be nil.
+func NewSTIXBundle(options *STIXOptions, persons ...*Person) *STIXBundle {
+	timestamp := time.Now()
+	if options != nil && !options.Timestamp.IsZero() {
+		timestamp = options.Timestamp
+	}
+	builder := &stixBuilder{timestamp: timestamp.UTC().Format("2006-01-02T15:04:05.000Z"), seen: make(map[string]bool)}
+	for i, person := range persons {
+		builder.addPerson(person, fmt.Sprintf("persons[%d]:%s", i, contentKey(person)))
+	}
+	ids := make([]string, len(builder.objects))
+	for i, object := range builder.objects {
+		ids[i] = object.ID()
+	}
+	return &STIXBundle{
+		Type:    "bundle",
+		ID:      "bundle--" + uuidV5(piplSTIXNamespace, strings.Join(ids, ",")),
+		Objects: builder.objects,
+	}
+}
+
+// stixBuilder accumulates deduplicated STIX objects.
+type stixBuilder struct {
+	timestamp string
+	objects   []STIXObject
+	seen      map[string]bool
+}
+
+func (builder *stixBuilder) add(object STIXObject) string {
+	id := object.ID()
+	if !builder.seen[id] {
+		builder.seen[id] = true
+		builder.objects = append(builder.objects, object)
+	}
+	return id
+}
+
+// addPerson adds the identity of person, its observables and its relatives,
+// and returns the identity's ID. fallback keys the identity if the person has
+// no Pipl ID, strong identifier or search pointer.
+func (builder *stixBuilder) addPerson(person *Person, fallback string) string {
+	key := stixKey(person)
+	name := firstNonEmpty(key, "unknown")
+	if key == "" {
+		key = fallback
+	}
+	identity := STIXObject{
+		"type":           "identity",
+		"spec_version":   "2.1",
+		"id":             "identity--" + uuidV5(piplSTIXNamespace, key),
+		"created":        builder.timestamp,
+		"modified":       builder.timestamp,
+		"identity_class": "individual",
+	}
+	names := labels(person.PersonFields.items(CategoryNames))
+	if len(names) > 0 {
+		identity["name"] = names[0]
+	} else {
+		identity["name"] = name
+	}
+	var contacts []string
+	for _, category := range []FieldCategory{CategoryEmails, CategoryPhones, CategoryAddresses} {
+		contacts = append(contacts, labels(person.PersonFields.items(category))...)
+	}
+	if len(contacts) > 0 {
+		identity["contact_information"] = strings.Join(contacts, "\n")
+	}
+	var references []interface{}
+	for _, url := range person.URLs {
+		if url.URL != "" {
+			references = append(references, STIXObject{"source_name": firstNonEmpty(url.Name, url.Domain, "pipl"), "url": url.URL})
+		}
+	}
+	if person.ID != "" {
+		references = append([]interface{}{STIXObject{"source_name": "pipl", "external_id": string(person.ID)}}, references...)
+	}
+	if len(references) > 0 {
+		identity["external_references"] = references
+	}
+	identityID := builder.add(identity)
+
+	for _, email := range person.Emails {
+		if email.Address != "" {
+			builder.relate(identityID, builder.add(stixObservable("email-addr", STIXObject{"value": email.Address})), "")
+		}
+	}
+	for _, username := range person.Usernames {
+		if username.Content != "" {
+			login, service := splitService(username.Content)
+			builder.relate(identityID, builder.add(stixObservable("user-account", stixAccount(service, "account_login", login))), "")
+		}
+	}
+	for _, userID := range person.UserIDs {
+		if userID.Content != "" {
+			id, service := splitService(userID.Content)
+			builder.relate(identityID, builder.add(stixObservable("user-account", stixAccount(service, "user_id", id))), "")
+		}
+	}
+	for i := range person.Relationships {
+		relationship := &person.Relationships[i]
+		relativeID := builder.addPerson(relationship.ToPerson(), fmt.Sprintf("%s|relationships[%d]:%s", key, i, contentKey(relationship)))
+		builder.relate(identityID, relativeID, joinNonEmpty("/", string(relationship.Type), string(relationship.Subtype)))
+	}
+	return identityID
+}
+
+// stixKey returns what the identity of person is keyed on: its Pipl ID, its
+// first email, phone, username or user ID, or its search pointer. Names are too
+// weak to tell people apart across exports, so persons with nothing else get
+// "".
+func stixKey(person *Person) string {
+	if key := nodeID(person); key != "" && !strings.HasPrefix(key, string(CategoryNames)+":") {
+		return key
+	}
+	if person.SearchPointer != "" {
+		return "search_pointer:" + person.SearchPointer
+	}
+	return ""
+}
+
+// contentKey renders value as JSON, to key identities on their content.
+func contentKey(value interface{}) string {
+	data, _ := json.Marshal(value)
+	return string(data)
+}
+
+// relate adds a "related-to" relationship between two objects.
+func (builder *stixBuilder) relate(source string, target string, description string) {
+	relationship := STIXObject{
+		"type":              "relationship",
+		"spec_version":      "2.1",
+		"id":                "relationship--" + uuidV5(piplSTIXNamespace, source+"|related-to|"+target),
+		"created":           builder.timestamp,
+		"modified":          builder.timestamp,
+		"relationship_type": "related-to",
+		"source_ref":        source,
+		"target_ref":        target,
+	}
+	if description != "" {
+		relationship["description"] = description
+	}
+	builder.add(relationship)
+}
+
+// stixObservable builds a cyber observable with the given ID contributing
+// properties, deriving its ID from their canonical JSON form as the STIX 2.1
+// specification requires.
+func stixObservable(objectType string, properties STIXObject) STIXObject {
+	var canonical bytes.Buffer
+	encoder := json.NewEncoder(&canonical)
+	encoder.SetEscapeHTML(false)
+	encoder.Encode(properties)
+	object := STIXObject{
+		"type":         objectType,
+		"spec_version": "2.1",
+		"id":           objectType + "--" + uuidV5(STIXNamespace, strings.TrimSpace(canonical.String())),
+	}
+	for key, value := range properties {
+		object[key] = value
+	}
+	return object
+}
+
+// stixAccount returns the ID contributing properties of a user account.
+func stixAccount(service string, property string, value string) STIXObject {
+	account := STIXObject{property: value}
+	if service != "" {
+		account["account_type"] = service
+	}
+	return account
+}
+
+// splitService splits a Pipl username or user ID such as "superman@facebook"
+// into the account and the service it belongs to.
+func splitService(content string) (account string, service string) {
+	if at := strings.LastIndex(content, "@"); at > 0 && at < len(content)-1 {
+		return content[:at], content[at+1:]
+	}
+	return content, ""
+}
+
+// labels returns the non-empty labels of items.
+func labels(items []fieldItem) []string {
+	var result []string
+	for _, item := range items {
+		if label := item.label(); label != "" {
+			result = append(result, label)
+		}
+	}
+	return result
+}
+
+// uuidV5 returns the version 5 (SHA-1, name-based) UUID of name in namespace,
+// as defined by RFC 4122.
+func uuidV5(namespace string, name string) string {
+	namespaceBytes, _ := hex.DecodeString(strings.Replace(namespace, "-", "", -1))
+	hash := sha1.New()
+	hash.Write(namespaceBytes)
+	hash.Write([]byte(name))
+	sum := hash.Sum(nil)[:16]
+	sum[6] = sum[6]&0x0f | 0x50
+	sum[8] = sum[8]&0x3f | 0x80
+	encoded := hex.EncodeToString(sum)
+	return encoded[:8] + "-" + encoded[8:12] + "-" + encoded[12:16] + "-" + encoded[16:20] + "-" + encoded[20:]
+}
@@ -0,0 +1,82 @@
+package pipl_test
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/xpcmdshell/pipl"
+)
+
+func TestSTIXBundle(t *testing.T) {
+	response, _ := loadResponse(t, "person_response.json")
+	options := &pipl.STIXOptions{Timestamp: time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)}
+	bundle := response.STIXBundle(options)
+
+	counts := make(map[string]int)
+	objects := make(map[string]pipl.STIXObject)
+	for _, object := range bundle.Objects {
+		counts[object.Type()]++
+		objects[object.ID()] = object
+	}
+	// Clark, Jonathan and Lois; two emails for Clark and one for Lois; a
+	// username and a user ID
+	if counts["identity"] != 3 || counts["email-addr"] != 3 || counts["user-account"] != 2 || counts["relationship"] != 7 {
+		t.Errorf("unexpected objects: %v", counts)
+	}
+	email, ok := objects["email-addr--0fee3e66-58b0-5e79-b738-8a538f232fb9"]
+	if !ok || email["value"] != "clark@dailyplanet.example.com" {
+		t.Errorf("email-addr ID doesn't follow the STIX deterministic ID rules: %v", objects)
+	}
+	if account, ok := objects["user-account--145b8a97-55ae-5a1c-ab48-13d6483b6a2e"]; !ok || account["account_type"] != "facebook" {
+		t.Errorf("user-account ID doesn't follow the STIX deterministic ID rules: %v", objects)
+	}
+	if created := bundle.Objects[0]["created"]; created != "2020-01-02T03:04:05.000Z" {
+		t.Errorf("unexpected created time %v", created)
+	}
+
+	again, _ := loadResponse(t, "person_response.json")
+	if repeated := again.STIXBundle(options); !reflect.DeepEqual(bundle, repeated) {
+		t.Error("repeated exports should be identical")
+	}
+	if _, err := json.Marshal(bundle); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSTIXAnonymousIdentities(t *testing.T) {
+	first, second := pipl.NewPerson(), pipl.NewPerson()
+	first.Addresses = []pipl.Address{{City: "Smallville"}}
+	second.Addresses = []pipl.Address{{City: "Metropolis"}}
+	pointer := &pipl.Person{SearchPointer: "0123456789abcdef"}
+	options := &pipl.STIXOptions{Timestamp: time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)}
+
+	bundle := pipl.NewSTIXBundle(options, first, second, pointer)
+	if len(bundle.Objects) != 3 || bundle.Objects[0].ID() == bundle.Objects[1].ID() {
+		t.Errorf("persons without identifiers share an identity: %v", bundle.Objects)
+	}
+	if repeated := pipl.NewSTIXBundle(options, first, second, pointer); !reflect.DeepEqual(bundle, repeated) {
+		t.Error("repeated exports of persons without identifiers should be identical")
+	}
+	if repeated := pipl.NewSTIXBundle(options, pointer); repeated.Objects[0].ID() != bundle.Objects[2].ID() {
+		t.Error("identities keyed on the search pointer should be stable")
+	}
+
+	// Relatives known only by name are told apart by whose relative they are
+	clark, bruce := &pipl.Person{ID: "clark"}, &pipl.Person{ID: "bruce"}
+	for _, person := range []*pipl.Person{clark, bruce} {
+		relationship := pipl.Relationship{Type: pipl.RelationshipTypeFamily}
+		relationship.Names = []pipl.Name{{First: "John", Last: "Smith"}}
+		person.AddRelationship(relationship)
+	}
+	identities := make(map[string]bool)
+	for _, object := range pipl.NewSTIXBundle(options, clark, bruce).Objects {
+		if object.Type() == "identity" {
+			identities[object.ID()] = true
+		}
+	}
+	if len(identities) != 4 {
+		t.Errorf("expected 4 distinct identities, got %d", len(identities))
+	}
+}
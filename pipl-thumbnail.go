@@ -0,0 +1,68 @@
+package pipl
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// ThumbnailOptions holds the optional settings for a thumbnail request.
+type ThumbnailOptions struct {
+	// Favicon overlays the favicon of the site the image came from
+	Favicon bool
+
+	// ZoomFace crops the thumbnail around the face in the image, if one is found
+	ZoomFace bool
+
+	// Fallback lists more thumbnail tokens to try, in order, if the image for the
+	// primary token can't be retrieved
+	Fallback []string
+}
+
+// Thumbnail retrieves a thumbnail of an image by its thumbnail token (see
+// Image.ThumbnailToken), scaled to the requested width and height. It returns
+// the image data and its content type (e.g. "image/jpeg"). Like searches, the
+// request goes through the client's Limiter and is retried as set by
+// MaxRetries. Options may be nil.
+func (searchClient *Client) Thumbnail(ctx context.Context, token string, width int, height int, options *ThumbnailOptions) ([]byte, string, error) {
+	if token == "" {
+		return nil, "", errors.New("A thumbnail token is required")
+	}
+	if width <= 0 || height <= 0 {
+		return nil, "", errors.New("Thumbnail width and height must be positive")
+	}
+	if options == nil {
+		options = new(ThumbnailOptions)
+	}
+	tokens := append([]string{token}, options.Fallback...)
+	query := url.Values{}
+	query.Add("tokens", strings.Join(tokens, ","))
+	query.Add("width", strconv.Itoa(width))
+	query.Add("height", strconv.Itoa(height))
+	query.Add("favicon", strconv.FormatBool(options.Favicon))
+	query.Add("zoom_face", strconv.FormatBool(options.ZoomFace))
+
+	endpoint := searchClient.ThumbnailEndpoint
+	if endpoint == "" {
+		endpoint = PiplThumbnailEndpoint
+	}
+	request, err := http.NewRequestWithContext(ctx, "GET", endpoint+"?"+query.Encode(), nil)
+	if err != nil {
+		return nil, "", err
+	}
+	body, response, err := searchClient.do(request)
+	if err != nil {
+		return nil, "", err
+	}
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return nil, "", &ErrHTTPStatus{StatusCode: response.StatusCode, Status: response.Status, Body: body}
+	}
+	contentType := response.Header.Get("Content-Type")
+	if contentType == "" {
+		contentType = http.DetectContentType(body)
+	}
+	return body, contentType, nil
+}
@@ -0,0 +1,86 @@
+package pipl_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/xpcmdshell/pipl"
+)
+
+func TestThumbnail(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if query.Get("tokens") == "missing" {
+			http.Error(w, "not found", http.StatusNotFound)
+			return
+		}
+		if query.Get("tokens") != "primary,backup" || query.Get("width") != "120" || query.Get("height") != "90" ||
+			query.Get("favicon") != "true" || query.Get("zoom_face") != "false" {
+			t.Errorf("unexpected query: %s", r.URL.RawQuery)
+		}
+		w.Header().Set("Content-Type", "image/png")
+		w.Write([]byte("\x89PNG"))
+	}))
+	defer server.Close()
+
+	client := pipl.NewClient("test")
+	client.ThumbnailEndpoint = server.URL
+	options := &pipl.ThumbnailOptions{Favicon: true, Fallback: []string{"backup"}}
+	data, contentType, err := client.Thumbnail(context.Background(), "primary", 120, 90, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if contentType != "image/png" || string(data) != "\x89PNG" {
+		t.Errorf("unexpected thumbnail: %q %q", contentType, data)
+	}
+
+	_, _, err = client.Thumbnail(context.Background(), "missing", 120, 90, nil)
+	if statusErr, ok := err.(*pipl.ErrHTTPStatus); !ok || statusErr.StatusCode != http.StatusNotFound {
+		t.Errorf("expected an ErrHTTPStatus, got %v", err)
+	}
+}
+
+// countingLimiter counts the requests it lets through.
+type countingLimiter struct{ waits int }
+
+func (limiter *countingLimiter) Wait(ctx context.Context) error {
+	limiter.waits++
+	return ctx.Err()
+}
+
+func TestThumbnailRetries(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests < 3 {
+			http.Error(w, "busy", http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "image/png")
+		w.Write([]byte("\x89PNG"))
+	}))
+	defer server.Close()
+
+	limiter := &countingLimiter{}
+	client := pipl.NewClient("test")
+	client.ThumbnailEndpoint = server.URL
+	client.Limiter = limiter
+	client.MaxRetries = 2
+	client.RetryBackoff = time.Millisecond
+	data, _, err := client.Thumbnail(context.Background(), "primary", 120, 90, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "\x89PNG" || requests != 3 || limiter.waits != 3 {
+		t.Errorf("got %q after %d requests and %d limiter waits", data, requests, limiter.waits)
+	}
+
+	requests, client.MaxRetries = 0, 1
+	_, _, err = client.Thumbnail(context.Background(), "primary", 120, 90, nil)
+	if statusErr, ok := err.(*pipl.ErrHTTPStatus); !ok || statusErr.StatusCode != http.StatusServiceUnavailable || requests != 2 {
+		t.Errorf("expected a 503 after 2 requests, got %v after %d", err, requests)
+	}
+}
@@ -0,0 +1,505 @@
+package pipl
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"io/ioutil"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// VCardOptions controls which data is exported to vCards.
+type VCardOptions struct {
+	// CurrentOnly exports only data marked as current. Names are always
+	// exported, since a vCard needs a formatted name.
+	CurrentOnly bool
+
+	// ExcludeInferred leaves out data that Pipl inferred rather than observed
+	ExcludeInferred bool
+}
+
+// vCardPhoneTypes maps phone types onto vCard TYPE parameters.
+var vCardPhoneTypes = map[PhoneType]string{
+	PhoneTypeMobile:    "cell",
+	PhoneTypeHomePhone: "home,voice",
+	PhoneTypeHomeFax:   "home,fax",
+	PhoneTypeWorkPhone: "work,voice",
+	PhoneTypeWorkFax:   "work,fax",
+	PhoneTypePager:     "pager",
+}
+
+// vCardEmailTypes maps email types onto vCard TYPE parameters.
+var vCardEmailTypes = map[EmailType]string{
+	EmailTypePersonal: "home",
+	EmailTypeWork:     "work",
+}
+
+// vCardAddressTypes maps address types onto vCard TYPE parameters. Old
+// addresses have no vCard equivalent and are exported without a type.
+var vCardAddressTypes = map[AddressType]string{
+	AddressTypeHome: "home",
+	AddressTypeWork: "work",
+}
+
+// ToVCard renders the person as a vCard 4.0 (RFC 6350). Names map to FN, N
+// and NICKNAME, emails to EMAIL, phones to TEL (using the international
+// format), addresses to ADR, jobs to ORG and TITLE, URLs to URL, images to
+// PHOTO, gender to GENDER and the date of birth to BDAY. Options may be nil.
+func (person *Person) ToVCard(options *VCardOptions) string {
+	if options == nil {
+		options = new(VCardOptions)
+	}
+	policy := FilterPolicy{
+		Default: ValidityPolicy{CurrentOnly: options.CurrentOnly, ExcludeInferred: options.ExcludeInferred},
+		Categories: map[FieldCategory]ValidityPolicy{
+			CategoryNames: {ExcludeInferred: options.ExcludeInferred},
+		},
+	}
+	filtered := person.Filter(policy)
+
+	card := new(vCardWriter)
+	card.line("BEGIN", nil, "VCARD")
+	card.line("VERSION", nil, "4.0")
+	if filtered.ID != "" {
+		card.line("UID", nil, "urn:pipl:"+string(filtered.ID))
+	}
+	formattedName := ""
+	for i, name := range filtered.Names {
+		if i == 0 {
+			formattedName = name.label()
+			card.line("N", nil, vCardStructured(name.Last, name.First, name.Middle, name.Prefix, name.Suffix))
+		} else if label := name.label(); label != "" && label != formattedName {
+			card.line("NICKNAME", nil, vCardEscape(label))
+		}
+	}
+	if formattedName == "" && len(filtered.Emails) > 0 {
+		formattedName = filtered.Emails[0].label()
+	}
+	card.line("FN", nil, vCardEscape(formattedName))
+	for _, email := range filtered.Emails {
+		if email.Address != "" {
+			card.line("EMAIL", vCardType(vCardEmailTypes[email.Type]), vCardEscape(email.Address))
+		}
+	}
+	for _, phone := range filtered.Phones {
+		params := vCardType(vCardPhoneTypes[phone.Type])
+		if phone.DisplayInternational != "" || phone.Number != 0 {
+			card.line("TEL", append([]string{"VALUE=uri"}, params...), vCardTelURI(phone))
+		} else if label := phone.label(); label != "" {
+			card.line("TEL", append([]string{"VALUE=text"}, params...), vCardEscape(label))
+		}
+	}
+	for _, address := range filtered.Addresses {
+		street := joinNonEmpty(" ", address.House, address.Street)
+		if street == "" && address.City == "" && address.Country == "" {
+			street = firstNonEmpty(address.Raw, address.Display)
+		}
+		card.line("ADR", vCardType(vCardAddressTypes[address.Type]),
+			vCardStructured(address.POBox, address.Apartment, street, address.City, address.State, address.ZipCode, address.Country))
+	}
+	// Each job's ORG and TITLE share a group, so they can be paired up again
+	group := 0
+	for _, job := range filtered.Jobs {
+		if job.Organization == "" && job.Title == "" {
+			continue
+		}
+		group++
+		prefix := "item" + strconv.Itoa(group) + "."
+		if job.Organization != "" {
+			card.line(prefix+"ORG", nil, vCardEscape(job.Organization))
+		}
+		if job.Title != "" {
+			card.line(prefix+"TITLE", nil, vCardEscape(job.Title))
+		}
+	}
+	for _, url := range filtered.URLs {
+		if url.URL != "" {
+			card.line("URL", nil, url.URL)
+		}
+	}
+	for _, image := range filtered.Images {
+		if image.URL != "" {
+			card.line("PHOTO", nil, image.URL)
+		}
+	}
+	if filtered.Gender != nil {
+		switch filtered.Gender.Content {
+		case GenderMale:
+			card.line("GENDER", nil, "M")
+		case GenderFemale:
+			card.line("GENDER", nil, "F")
+		}
+	}
+	if filtered.DateOfBirth != nil {
+		if dateRange := filtered.DateOfBirth.DateRange; dateRange != nil && dateRange.Start == dateRange.End && dateRange.Start.Precision() == DatePrecisionDay {
+			card.line("BDAY", nil, dateRange.Start.Time().Format("20060102"))
+		} else if label := filtered.DateOfBirth.label(); label != "" {
+			card.line("BDAY", []string{"VALUE=text"}, vCardEscape(label))
+		}
+	}
+	card.line("END", nil, "VCARD")
+	return card.String()
+}
+
+// WriteVCards writes the persons as a single vCard file. Options may be nil.
+func WriteVCards(w io.Writer, persons []*Person, options *VCardOptions) error {
+	for _, person := range persons {
+		if _, err := io.WriteString(w, person.ToVCard(options)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// vCardWriter accumulates the folded, CRLF-terminated lines of a vCard.
+type vCardWriter struct {
+	strings.Builder
+}
+
+// line writes a content line, folding it at 75 octets as RFC 6350 requires.
+// Continuation lines start with a space, which counts towards the limit.
+func (card *vCardWriter) line(name string, params []string, value string) {
+	content := name
+	for _, param := range params {
+		content += ";" + param
+	}
+	content += ":" + value
+	limit := 75
+	for len(content) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(content[cut]) {
+			cut--
+		}
+		card.WriteString(content[:cut] + "\r\n ")
+		content = content[cut:]
+		limit = 74
+	}
+	card.WriteString(content + "\r\n")
+}
+
+// vCardType builds the TYPE parameter for a comma-separated list of types.
+func vCardType(types string) []string {
+	if types == "" {
+		return nil
+	}
+	return []string{"TYPE=" + types}
+}
+
+// vCardTelURI renders a phone as a tel: URI based on its international format,
+// e.g. "tel:+1-617-555-0123;ext=12". The number is used if that isn't set.
+func vCardTelURI(phone Phone) string {
+	var parts []string
+	for _, part := range strings.Fields(phone.DisplayInternational) {
+		if digits(part) == strings.TrimPrefix(part, "+") || strings.Contains(part, "-") {
+			parts = append(parts, part)
+		}
+	}
+	uri := "tel:" + strings.Join(parts, "-")
+	if len(parts) == 0 {
+		if phone.CountryCode != 0 {
+			uri += "+" + strconv.Itoa(phone.CountryCode) + "-"
+		}
+		uri += strconv.Itoa(phone.Number)
+	}
+	if phone.Extension != 0 {
+		uri += ";ext=" + strconv.Itoa(phone.Extension)
+	}
+	return uri
+}
+
+// vCardEscape escapes a text value.
+func vCardEscape(value string) string {
+	return strings.NewReplacer(`\`, `\\`, ",", `\,`, ";", `\;`, "\r\n", `\n`, "\n", `\n`).Replace(value)
+}
+
+// vCardStructured escapes and joins the components of a structured value (N, ADR).
+func vCardStructured(components ...string) string {
+	escaped := make([]string, len(components))
+	for i, component := range components {
+		escaped[i] = vCardEscape(component)
+	}
+	return strings.Join(escaped, ";")
+}
+
+// vCardProperty is a parsed content line.
+type vCardProperty struct {
+	group string
+	name  string
+	types map[string]bool
+	value string
+}
+
+// ParseVCards reads vCards (versions 3.0 and 4.0) and converts each one into a
+// search object holding its names, emails, phones, addresses, jobs, URLs,
+// photos, gender and birthday. Unsupported properties are ignored.
+func ParseVCards(r io.Reader) ([]*Person, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	unfolded := strings.NewReplacer("\r\n ", "", "\r\n\t", "", "\n ", "", "\n\t", "").Replace(string(data))
+	var persons []*Person
+	var current []vCardProperty
+	inCard := false
+	scanner := bufio.NewScanner(strings.NewReader(unfolded))
+	scanner.Buffer(make([]byte, 64*1024), len(unfolded)+1)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if line == "" {
+			continue
+		}
+		property, ok := parseVCardLine(line)
+		if !ok {
+			continue
+		}
+		switch {
+		case property.name == "BEGIN" && strings.EqualFold(property.value, "VCARD"):
+			inCard, current = true, nil
+		case property.name == "END" && strings.EqualFold(property.value, "VCARD"):
+			if inCard {
+				persons = append(persons, vCardPerson(current))
+			}
+			inCard = false
+		case inCard:
+			current = append(current, property)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if inCard {
+		return persons, errors.New("The vCard data ends in the middle of a card")
+	}
+	return persons, nil
+}
+
+// parseVCardLine splits a content line into its name, TYPE parameters and raw
+// value. Group prefixes ("item1.EMAIL") are kept apart from the name.
+func parseVCardLine(line string) (vCardProperty, bool) {
+	colon := strings.Index(line, ":")
+	if colon < 0 {
+		return vCardProperty{}, false
+	}
+	parts := strings.Split(line[:colon], ";")
+	group, name := "", strings.ToUpper(parts[0])
+	if dot := strings.LastIndex(name, "."); dot >= 0 {
+		group, name = strings.ToLower(name[:dot]), name[dot+1:]
+	}
+	property := vCardProperty{group: group, name: name, types: make(map[string]bool), value: line[colon+1:]}
+	for _, param := range parts[1:] {
+		equals := strings.Index(param, "=")
+		if equals < 0 {
+			property.types[strings.ToLower(param)] = true
+			continue
+		}
+		if !strings.EqualFold(param[:equals], "TYPE") {
+			continue
+		}
+		for _, value := range strings.Split(strings.Trim(param[equals+1:], `"`), ",") {
+			property.types[strings.ToLower(value)] = true
+		}
+	}
+	return property, true
+}
+
+// vCardUnescape reverses vCardEscape.
+func vCardUnescape(value string) string {
+	var builder strings.Builder
+	for i := 0; i < len(value); i++ {
+		if value[i] == '\\' && i+1 < len(value) {
+			i++
+			if value[i] == 'n' || value[i] == 'N' {
+				builder.WriteByte('\n')
+			} else {
+				builder.WriteByte(value[i])
+			}
+			continue
+		}
+		builder.WriteByte(value[i])
+	}
+	return builder.String()
+}
+
+// vCardComponents splits a structured value on unescaped semicolons and
+// unescapes each component. The result always has at least count components.
+func vCardComponents(value string, count int) []string {
+	var components []string
+	start := 0
+	for i := 0; i < len(value); i++ {
+		if value[i] == '\\' {
+			i++
+			continue
+		}
+		if value[i] == ';' {
+			components = append(components, vCardUnescape(value[start:i]))
+			start = i + 1
+		}
+	}
+	components = append(components, vCardUnescape(value[start:]))
+	for len(components) < count {
+		components = append(components, "")
+	}
+	return components
+}
+
+// vCardPerson converts the properties of a single vCard into a search object.
+func vCardPerson(properties []vCardProperty) *Person {
+	person := NewPerson()
+	formattedName := ""
+	// ORG and TITLE properties in the same group describe the same job.
+	// Ungrouped ones, as most vCards hold a single job, are paired in order.
+	groups := make(map[string]*Job)
+	var grouped []*Job
+	var titles, organizations []string
+	job := func(group string) *Job {
+		if groups[group] == nil {
+			groups[group] = new(Job)
+			grouped = append(grouped, groups[group])
+		}
+		return groups[group]
+	}
+	for _, property := range properties {
+		switch property.name {
+		case "FN":
+			formattedName = vCardUnescape(property.value)
+		case "N":
+			parts := vCardComponents(property.value, 5)
+			if joinNonEmpty("", parts...) != "" {
+				person.Names = append(person.Names, Name{Last: parts[0], First: parts[1], Middle: parts[2], Prefix: parts[3], Suffix: parts[4]})
+			}
+		case "NICKNAME":
+			person.Names = append(person.Names, Name{Type: NameTypeAlias, Raw: vCardUnescape(property.value)})
+		case "EMAIL":
+			email := Email{Address: vCardUnescape(property.value)}
+			for emailType, vCardTypes := range vCardEmailTypes {
+				if property.types[vCardTypes] {
+					email.Type = emailType
+				}
+			}
+			person.Emails = append(person.Emails, email)
+		case "TEL":
+			person.Phones = append(person.Phones, vCardPhone(property))
+		case "ADR":
+			person.Addresses = append(person.Addresses, vCardAddress(property))
+		case "ORG":
+			if organization := vCardComponents(property.value, 1)[0]; property.group != "" {
+				job(property.group).Organization = organization
+			} else {
+				organizations = append(organizations, organization)
+			}
+		case "TITLE":
+			if title := vCardUnescape(property.value); property.group != "" {
+				job(property.group).Title = title
+			} else {
+				titles = append(titles, title)
+			}
+		case "URL":
+			person.URLs = append(person.URLs, URL{URL: property.value})
+		case "PHOTO":
+			if strings.HasPrefix(property.value, "http") {
+				person.Images = append(person.Images, Image{URL: property.value})
+			}
+		case "GENDER":
+			switch strings.ToUpper(vCardComponents(property.value, 1)[0]) {
+			case "M":
+				person.Gender = &Gender{Content: GenderMale}
+			case "F":
+				person.Gender = &Gender{Content: GenderFemale}
+			}
+		case "BDAY":
+			if birthday := vCardDate(property.value); birthday != "" {
+				person.SetDateOfBirth(birthday)
+			}
+		}
+	}
+	if len(person.Names) == 0 || (person.Names[0].Type == NameTypeAlias && formattedName != "") {
+		if formattedName != "" {
+			person.Names = append([]Name{{Raw: formattedName}}, person.Names...)
+		}
+	} else if person.Names[0].Raw == "" {
+		person.Names[0].Display = formattedName
+	}
+	for _, job := range grouped {
+		person.Jobs = append(person.Jobs, *job)
+	}
+	for i := 0; i < len(titles) || i < len(organizations); i++ {
+		job := Job{}
+		if i < len(titles) {
+			job.Title = titles[i]
+		}
+		if i < len(organizations) {
+			job.Organization = organizations[i]
+		}
+		person.Jobs = append(person.Jobs, job)
+	}
+	return person
+}
+
+// vCardPhone converts a TEL property, in either tel: URI or text form.
+func vCardPhone(property vCardProperty) Phone {
+	phone := Phone{}
+	value := property.value
+	if strings.HasPrefix(strings.ToLower(value), "tel:") {
+		value = value[len("tel:"):]
+		if ext := strings.Index(strings.ToLower(value), ";ext="); ext >= 0 {
+			phone.Extension, _ = strconv.Atoi(digits(value[ext+len(";ext="):]))
+			value = value[:ext]
+		}
+	} else {
+		value = vCardUnescape(value)
+	}
+	phone.Raw = value
+	if strings.HasPrefix(value, "+") {
+		if dash := strings.Index(value, "-"); dash > 1 {
+			countryCode, errCountry := strconv.Atoi(value[1:dash])
+			number, errNumber := strconv.Atoi(digits(value[dash+1:]))
+			if errCountry == nil && errNumber == nil {
+				phone.CountryCode, phone.Number, phone.Raw = countryCode, number, ""
+			}
+		}
+	}
+	switch {
+	case property.types["cell"]:
+		phone.Type = PhoneTypeMobile
+	case property.types["pager"]:
+		phone.Type = PhoneTypePager
+	case property.types["fax"] && property.types["work"]:
+		phone.Type = PhoneTypeWorkFax
+	case property.types["fax"]:
+		phone.Type = PhoneTypeHomeFax
+	case property.types["work"]:
+		phone.Type = PhoneTypeWorkPhone
+	case property.types["home"]:
+		phone.Type = PhoneTypeHomePhone
+	}
+	return phone
+}
+
+// vCardAddress converts an ADR property. A leading house number is split off
+// the street, as Pipl keeps it in a separate field.
+func vCardAddress(property vCardProperty) Address {
+	parts := vCardComponents(property.value, 7)
+	address := Address{POBox: parts[0], Apartment: parts[1], Street: parts[2], City: parts[3], State: parts[4], ZipCode: parts[5], Country: parts[6]}
+	if fields := strings.Fields(address.Street); len(fields) > 1 && digits(fields[0]) != "" {
+		address.House = fields[0]
+		address.Street = strings.Join(fields[1:], " ")
+	}
+	for addressType, vCardTypes := range vCardAddressTypes {
+		if property.types[vCardTypes] {
+			address.Type = addressType
+		}
+	}
+	return address
+}
+
+// vCardDate converts a vCard date ("19860618" or "1986-06-18") into the
+// "YYYY-MM-DD" form used by SetDateOfBirth, or "" if it isn't a full date.
+func vCardDate(value string) string {
+	value = strings.Split(value, "T")[0]
+	if compact := strings.Replace(value, "-", "", -1); len(compact) == 8 && digits(compact) == compact {
+		return compact[:4] + "-" + compact[4:6] + "-" + compact[6:]
+	}
+	return ""
+}
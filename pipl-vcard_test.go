@@ -0,0 +1,126 @@
+package pipl_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/xpcmdshell/pipl"
+)
+
+func TestVCardExport(t *testing.T) {
+	response, _ := loadResponse(t, "person_response.json")
+	card := response.Person.ToVCard(nil)
+	for _, line := range []string{
+		"BEGIN:VCARD\r\nVERSION:4.0\r\n",
+		"\r\nFN:Clark Joseph Kent\r\n",
+		"\r\nTEL;VALUE=uri;TYPE=cell:tel:+1-978-555-0145\r\n",
+		"\r\nTEL;VALUE=uri;TYPE=home,voice:tel:+1-617-555-0123;ext=12\r\n",
+		"\r\nADR;TYPE=home:;1;10 Hickory Lane;Smallville;KS;66605;US\r\n",
+		"\r\nGENDER:M\r\n",
+		"\r\nBDAY:19860618\r\n",
+		"\r\nEND:VCARD\r\n",
+	} {
+		if !strings.Contains(card, line) {
+			t.Errorf("vCard is missing %q:\n%s", line, card)
+		}
+	}
+	for _, line := range strings.Split(card, "\r\n") {
+		if len(line) > 75 {
+			t.Errorf("line not folded: %q", line)
+		}
+	}
+
+	current := response.Person.ToVCard(&pipl.VCardOptions{CurrentOnly: true})
+	if strings.Contains(current, "617-555-0123") || strings.Contains(current, "Metropolis") || !strings.Contains(current, "FN:") {
+		t.Errorf("CurrentOnly kept stale data:\n%s", current)
+	}
+}
+
+func TestVCardRoundTrip(t *testing.T) {
+	response, _ := loadResponse(t, "person_response.json")
+	var buffer bytes.Buffer
+	if err := pipl.WriteVCards(&buffer, []*pipl.Person{&response.Person, response.Person.Relationships[1].ToPerson()}, nil); err != nil {
+		t.Fatal(err)
+	}
+	persons, err := pipl.ParseVCards(&buffer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(persons) != 2 {
+		t.Fatalf("expected 2 persons, got %d", len(persons))
+	}
+	parsed := persons[0]
+	for _, entry := range pipl.DiffPersons(&response.Person, parsed).Category(pipl.CategoryEmails) {
+		if entry.Kind != pipl.DiffChanged {
+			t.Errorf("email %s %s", entry.Label, entry.Kind)
+		}
+	}
+	if len(parsed.Phones) != 2 || parsed.Phones[1].Number != 6175550123 || parsed.Phones[1].Extension != 12 || parsed.Phones[1].Type != pipl.PhoneTypeHomePhone {
+		t.Errorf("unexpected phones: %+v", parsed.Phones)
+	}
+	if address := parsed.Addresses[0]; address.House != "10" || address.Street != "Hickory Lane" || address.Apartment != "1" || address.Type != pipl.AddressTypeHome {
+		t.Errorf("unexpected address: %+v", address)
+	}
+	if parsed.Gender == nil || parsed.Gender.Content != pipl.GenderMale || parsed.DateOfBirth == nil || parsed.DateOfBirth.DateRange.Start != "1986-06-18" {
+		t.Errorf("unexpected gender or dob: %+v %+v", parsed.Gender, parsed.DateOfBirth)
+	}
+}
+
+func TestParseVCardsEscaping(t *testing.T) {
+	input := "BEGIN:VCARD\nVERSION:3.0\nN:Lane;Lois;;;\nFN:Lois Lane\nORG:Daily Planet\\, Inc.\nTITLE:Reporter\nitem1.EMAIL;TYPE=INTERNET;TYPE=WORK:lois@dailypl\n anet.example.com\nEND:VCARD\n"
+	persons, err := pipl.ParseVCards(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(persons) != 1 {
+		t.Fatalf("expected 1 person, got %d", len(persons))
+	}
+	person := persons[0]
+	if person.Names[0].First != "Lois" || person.Jobs[0].Organization != "Daily Planet, Inc." || person.Jobs[0].Title != "Reporter" {
+		t.Errorf("unexpected person: %+v", person)
+	}
+	if email := person.Emails[0]; email.Address != "lois@dailyplanet.example.com" || email.Type != pipl.EmailTypeWork {
+		t.Errorf("unexpected email: %+v", email)
+	}
+
+	if _, err := pipl.ParseVCards(strings.NewReader("BEGIN:VCARD\nFN:Lois Lane\n")); err == nil {
+		t.Error("expected an error for a truncated vCard")
+	}
+}
+
+func TestVCardFolding(t *testing.T) {
+	person := pipl.NewPerson()
+	person.AddNameRaw("Clark Kent")
+	long := "https://www.example.com/" + strings.Repeat("superman-", 30) + "é"
+	person.URLs = []pipl.URL{{URL: long}}
+	card := person.ToVCard(nil)
+	for _, line := range strings.Split(card, "\r\n") {
+		if len(line) > 75 {
+			t.Errorf("line longer than 75 octets (%d): %q", len(line), line)
+		}
+	}
+	persons, err := pipl.ParseVCards(strings.NewReader(card))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(persons) != 1 || len(persons[0].URLs) != 1 || persons[0].URLs[0].URL != long {
+		t.Errorf("folded value not restored: %+v", persons)
+	}
+}
+
+func TestVCardJobs(t *testing.T) {
+	person := pipl.NewPerson()
+	person.AddNameRaw("Clark Kent")
+	person.Jobs = []pipl.Job{{Title: "Field Reporter"}, {Organization: "Daily Planet"}, {Title: "Hero", Organization: "Justice League"}}
+	persons, err := pipl.ParseVCards(strings.NewReader(person.ToVCard(nil)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	jobs := persons[0].Jobs
+	if len(jobs) != 3 || jobs[0].Title != "Field Reporter" || jobs[0].Organization != "" ||
+		jobs[1].Title != "" || jobs[1].Organization != "Daily Planet" ||
+		jobs[2].Title != "Hero" || jobs[2].Organization != "Justice League" {
+		t.Errorf("jobs not paired up: %+v", jobs)
+	}
+}